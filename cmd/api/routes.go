@@ -0,0 +1,88 @@
+package main
+
+import (
+	"database/sql"
+	"go-rest-api/internal/api/handlers"
+	mw "go-rest-api/internal/api/middleware"
+	"go-rest-api/internal/api/router"
+	"go-rest-api/internal/auth"
+	utils "go-rest-api/pkg/utils"
+	"net/http"
+)
+
+// routes registers every endpoint. Role requirements for each resource live
+// in auth.Permissions.
+func routes(db *sql.DB, teachers *handlers.TeachersHandler, students *handlers.StudentsHandler, classes *handlers.ClassesHandler, subjects *handlers.SubjectsHandler, execs *handlers.ExecsHandler, search *handlers.SearchHandler, audit *handlers.AuditHandler) http.Handler {
+	r := router.New()
+
+	r.HandleFunc("GET /{$}", handlers.RootHandler)
+
+	r.Handle("GET /teachers/{$}", protected("teachers", teachers.List))
+	r.Handle("POST /teachers/{$}", protected("teachers", teachers.Create))
+	r.Handle("POST /teachers/import", protected("teachers", teachers.Import))
+	r.Handle("PATCH /teachers/{$}", protected("teachers", teachers.PatchMany))
+	r.Handle("DELETE /teachers/{$}", protected("teachers", teachers.DeleteMany))
+	r.Handle("GET /teachers/{id}", protected("teachers", teachers.Get))
+	r.Handle("PUT /teachers/{id}", protected("teachers", teachers.Update))
+	r.Handle("PATCH /teachers/{id}", protected("teachers", teachers.Patch))
+	r.Handle("DELETE /teachers/{id}", protected("teachers", teachers.Delete))
+	r.Handle("POST /teachers/{id}/restore", protectedAction("teachers", auth.ActionRestore, teachers.Restore))
+	r.Handle("GET /teachers/{id}/students", protected("students", teachers.Students))
+	r.Handle("GET /teachers/{id}/studentcount", protected("students", teachers.StudentCount))
+
+	r.Handle("GET /students/{$}", protected("students", students.List))
+	r.Handle("POST /students/{$}", protected("students", students.Create))
+	r.Handle("POST /students/import", protected("students", students.Import))
+	r.Handle("PATCH /students/{$}", protected("students", students.PatchMany))
+	r.Handle("DELETE /students/{$}", protected("students", students.DeleteMany))
+	r.Handle("GET /students/{id}", protected("students", students.Get))
+	r.Handle("PUT /students/{id}", protected("students", students.Update))
+	r.Handle("PATCH /students/{id}", protected("students", students.Patch))
+	r.Handle("DELETE /students/{id}", protected("students", students.Delete))
+	r.Handle("POST /students/{id}/restore", protectedAction("students", auth.ActionRestore, students.Restore))
+
+	r.Handle("GET /classes/{$}", protected("classes", classes.List))
+	r.Handle("POST /classes/{$}", protected("classes", classes.Create))
+	r.Handle("GET /classes/{class}", protected("classes", classes.Get))
+	r.Handle("PUT /classes/{class}", protected("classes", classes.Update))
+	r.Handle("DELETE /classes/{class}", protected("classes", classes.Delete))
+
+	r.Handle("GET /subjects/{$}", protected("subjects", subjects.List))
+	r.Handle("POST /subjects/{$}", protected("subjects", subjects.Create))
+	r.Handle("GET /subjects/{subject}", protected("subjects", subjects.Get))
+	r.Handle("PUT /subjects/{subject}", protected("subjects", subjects.Update))
+	r.Handle("DELETE /subjects/{subject}", protected("subjects", subjects.Delete))
+
+	r.HandleFunc("POST /execs/login", execs.Login)
+	r.HandleFunc("POST /execs/forgotpassword", execs.ForgotPassword)
+	r.HandleFunc("POST /execs/resetpassword/{token}", execs.ResetPassword)
+
+	r.Handle("GET /execs/{$}", protected("execs", execs.List))
+	r.Handle("POST /execs/{$}", protected("execs", execs.Create))
+	r.Handle("PATCH /execs/{$}", protected("execs", execs.PatchMany))
+	r.Handle("DELETE /execs/{$}", protected("execs", execs.DeleteMany))
+	r.Handle("GET /execs/{id}", protected("execs", execs.Get))
+	r.Handle("PUT /execs/{id}", protected("execs", execs.Update))
+	r.Handle("PATCH /execs/{id}", protected("execs", execs.Patch))
+	r.Handle("DELETE /execs/{id}", protected("execs", execs.Delete))
+	r.Handle("POST /execs/{id}/restore", protectedAction("execs", auth.ActionRestore, execs.Restore))
+
+	r.Handle("GET /search", protected("search", search.Search))
+
+	r.Handle("GET /audit", protected("audit", audit.List))
+
+	r.Handle("GET /stats/db", protected("stats", handlers.DBStatsHandler(db)))
+
+	return r
+}
+
+// protected requires a valid token and a role allowed by auth.Permissions
+func protected(resource string, handler http.HandlerFunc) http.Handler {
+	return utils.ApplyMiddlewares(handler, mw.Authorize(resource), mw.Authenticate)
+}
+
+// protectedAction is like protected for an action other than the one the
+// request method implies
+func protectedAction(resource string, action auth.Action, handler http.HandlerFunc) http.Handler {
+	return utils.ApplyMiddlewares(handler, mw.AuthorizeAction(resource, action), mw.Authenticate)
+}
@@ -1,11 +1,17 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
+	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
 	"go-rest-api/internal/api/handlers"
 	mw "go-rest-api/internal/api/middleware"
+	"go-rest-api/internal/auth"
+	"go-rest-api/internal/mail"
+	"go-rest-api/internal/migrate"
+	mysqlrepo "go-rest-api/internal/repository/mysql"
 	"go-rest-api/internal/repository/sqlconnect"
 	"log"
 	"net/http"
@@ -13,11 +19,23 @@ import (
 )
 
 func main() {
+	runMigrations := flag.Bool("migrate", false, "apply pending database migrations before starting")
+	migrationsDir := flag.String("migrations-dir", "db/migrations", "directory containing the migration files")
+	flag.Parse()
+
 	err := godotenv.Load()
 	if err != nil {
 		fmt.Println("Error loading .env file", err)
 	}
 
+	if err := auth.CheckSecret(); err != nil {
+		log.Fatalln(err)
+	}
+	mailer, err := mail.NewSenderFromEnv()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
 	port := os.Getenv("API_PORT")
 
 	cert := "cert.pem"
@@ -30,15 +48,27 @@ func main() {
 	}
 	defer db.Close()
 
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/", handlers.RootHandler)
+	if *runMigrations {
+		err = migrate.New(db, *migrationsDir).Up(context.Background())
+		if err != nil {
+			fmt.Println("Error applying migrations:", err)
+			return
+		}
+	}
 
-	mux.HandleFunc("/teachers/", handlers.TeachersHandler)
+	teacherRepo := mysqlrepo.NewTeacherRepository(db)
+	studentRepo := mysqlrepo.NewStudentRepository(db)
+	classRepo := mysqlrepo.NewClassRepository(db)
 
-	mux.HandleFunc("/students/", handlers.StudentsHandler)
+	teachersHandler := handlers.NewTeachersHandler(teacherRepo, studentRepo)
+	studentsHandler := handlers.NewStudentsHandler(studentRepo)
+	classesHandler := handlers.NewClassesHandler(classRepo, teacherRepo, studentRepo)
+	subjectsHandler := handlers.NewSubjectsHandler(mysqlrepo.NewSubjectRepository(db))
+	execsHandler := handlers.NewExecsHandler(mysqlrepo.NewExecRepository(db), mailer)
+	searchHandler := handlers.NewSearchHandler(mysqlrepo.NewSearchRepository(db))
+	auditHandler := handlers.NewAuditHandler(mysqlrepo.NewAuditRepository(db))
 
-	mux.HandleFunc("/execs/", handlers.ExecsHandler)
+	mux := routes(db, teachersHandler, studentsHandler, classesHandler, subjectsHandler, execsHandler, searchHandler, auditHandler)
 
 	tlsConfig := &tls.Config{
 		MinVersion: tls.VersionTLS12,
@@ -57,7 +87,7 @@ func main() {
 		Addr: port,
 		//Handler:   mw.Hpp(hppOptions)(rl.Middleware(mw.ResponseTimeMiddleware(mw.SecurityHeaders(mw.Cors(mux))))),
 		//secureMux := utils.ApplyMiddlewares(mux, mw.Hpp(hppOptions), mw.Compression, mw.SecurityHeaders, mw.ResponseTimeMiddleware, rl.Middleware, mw.Cors)
-		Handler:   mw.SecurityHeaders(mux),
+		Handler:   mw.RequestID(mw.AuditActor(mw.SecurityHeaders(mux))),
 		TLSConfig: tlsConfig,
 	}
 
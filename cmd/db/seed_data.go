@@ -1,11 +1,15 @@
 package main
 
 import (
-	"crypto/rand"
 	"database/sql"
+	"encoding/binary"
 	"encoding/hex"
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/sqlconnect"
 	"log"
 	"os"
 	"strings"
@@ -15,27 +19,15 @@ import (
 	"golang.org/x/crypto/bcrypt"
 	mathrand "math/rand/v2"
 
-	_ "github.com/go-sql-driver/mysql"
+	driver "github.com/go-sql-driver/mysql"
 )
 
-// Subject options for teachers
-var subjects = []string{
-	"Mathematics", "Physics", "Chemistry", "Biology", "History",
-	"Geography", "English", "Literature", "Computer Science", "Art",
-	"Music", "Physical Education", "Economics", "Psychology", "Philosophy",
-}
-
-// Class options
-var classes = []string{
-	"1A", "1B", "1C", "2A", "2B", "2C", "3A", "3B", "3C",
-	"4A", "4B", "4C", "5A", "5B", "5C", "6A", "6B", "6C",
-}
+// Role options shared with request validation
+var roles = models.Roles
 
-// Role options for executives
-var roles = []string{
-	"Principal", "Vice Principal", "Head of Department", "Administrator",
-	"Counselor", "IT Manager", "Financial Officer", "HR Manager",
-}
+// Subject and class options, loaded from their tables so that seeded rows
+// satisfy the foreign keys
+var subjects, classes []string
 
 // Sample first names
 var firstNames = []string{
@@ -62,197 +54,368 @@ var lastNames = []string{
 	"Morgan", "Bell", "Murphy", "Bailey", "Rivera", "Cooper", "Richardson", "Cox",
 }
 
+// seededReferenceTime replaces time.Now for generated timestamps when -seed
+// is given, so the output doesn't depend on when the seeder runs
+var seededReferenceTime = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// maxPlaceholders is MySQL's limit on placeholders in a prepared statement
+const maxPlaceholders = 65535
+
+type seeder struct {
+	rng        *mathrand.Rand
+	now        time.Time
+	batchSize  int
+	maxRetries int
+	bcryptCost int
+	// used holds the emails and usernames generated so far, so unique
+	// columns are not retried against the database
+	used map[string]bool
+}
+
+// credential is the plaintext login of a generated exec
+type credential struct {
+	username string
+	password string
+}
+
 func main() {
+	teacherCount := flag.Int("teachers", 1000, "number of teachers to generate")
+	studentCount := flag.Int("students", 1000, "number of students to generate")
+	execCount := flag.Int("execs", 1000, "number of executives to generate")
+	seed := flag.Uint64("seed", 0, "random seed for reproducible data (0 picks a random seed)")
+	truncate := flag.Bool("truncate", false, "empty the tables before seeding, in the same transaction")
+	batchSize := flag.Int("batch-size", 100, "rows per INSERT statement")
+	maxRetries := flag.Int("max-retries", 5, "attempts per batch (and per unique value) before giving up")
+	bcryptCost := flag.Int("bcrypt-cost", bcrypt.DefaultCost, "bcrypt cost for exec passwords")
+	passwordsFile := flag.String("passwords-file", "", "write the generated exec usernames and plaintext passwords to this CSV file")
+	flag.Parse()
+
+	if *batchSize < 1 || *maxRetries < 1 {
+		log.Fatalln("batch-size and max-retries must be at least 1")
+	}
+
 	err := godotenv.Load()
 	if err != nil {
 		fmt.Println("Error loading .env file")
 	}
 
-	// Connect to the database
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-	dbport := os.Getenv("DB_PORT")
-	host := os.Getenv("HOST")
-	connectionString := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, password, host, dbport, dbname)
-
-	db, err := sql.Open("mysql", connectionString)
+	db, err := sqlconnect.ConnectDb()
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
-	// Ping the database to verify connection
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+	if subjects, err = loadNames(db, "subjects"); err != nil {
+		log.Fatalf("Failed to load subjects: %v", err)
+	}
+	if classes, err = loadNames(db, "classes"); err != nil {
+		log.Fatalf("Failed to load classes: %v", err)
+	}
+
+	s := &seeder{
+		now:        time.Now(),
+		batchSize:  *batchSize,
+		maxRetries: *maxRetries,
+		bcryptCost: *bcryptCost,
+		used:       make(map[string]bool),
+	}
+	if *seed != 0 {
+		s.now = seededReferenceTime
+	} else {
+		*seed = mathrand.Uint64()
+	}
+	s.rng = mathrand.New(mathrand.NewPCG(*seed, *seed))
+	fmt.Println("Using seed", *seed)
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Fatalf("Failed to begin transaction: %v", err)
 	}
 
-	// Generate and insert data
-	generateTeachers(db, 1000)
-	generateStudents(db, 1000)
-	generateExecutives(db, 1000)
+	// DELETE rather than TRUNCATE, which commits implicitly, so that a failed
+	// seed leaves the old rows in place
+	if *truncate {
+		for _, table := range []string{"executives", "students", "teachers"} {
+			if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+				tx.Rollback()
+				log.Fatalf("Failed to empty %s: %v", table, err)
+			}
+		}
+	}
+
+	var credentials []credential
+	err = s.generateTeachers(tx, *teacherCount)
+	if err == nil {
+		err = s.generateStudents(tx, *studentCount)
+	}
+	if err == nil {
+		credentials, err = s.generateExecutives(tx, *execCount)
+	}
+	if err != nil {
+		tx.Rollback()
+		log.Fatalf("Seeding failed, nothing was written: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Fatalf("Failed to commit seed data: %v", err)
+	}
+	if *truncate {
+		fmt.Println("Tables emptied")
+	}
+
+	if *passwordsFile != "" {
+		if err := writeCredentials(*passwordsFile, credentials); err != nil {
+			log.Fatalf("Failed to write passwords file: %v", err)
+		}
+		fmt.Printf("Exec credentials written to %s\n", *passwordsFile)
+	}
 
 	fmt.Println("Seed data generated successfully!")
 }
 
-// generateTeachers creates and inserts teacher records
-func generateTeachers(db *sql.DB, count int) {
-	stmt, err := db.Prepare(`
-		INSERT INTO teachers (id, first_name, last_name, subject, class, email)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`)
+// loadNames returns the names in a lookup table such as classes, sorted so a
+// given seed picks the same values every run
+func loadNames(db *sql.DB, table string) ([]string, error) {
+	rows, err := db.Query("SELECT name FROM " + table + " ORDER BY name")
 	if err != nil {
-		log.Fatalf("Failed to prepare teachers statement: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-	defer stmt.Close()
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s table is empty; run the migrations first", table)
+	}
+	return names, nil
+}
 
+// generateTeachers creates and inserts teacher records
+func (s *seeder) generateTeachers(tx *sql.Tx, count int) error {
 	fmt.Printf("Generating %d teacher records...\n", count)
 
-	for i := 0; i < count; i++ {
-		firstName := firstNames[mathrand.IntN(len(firstNames))]
-		lastName := lastNames[mathrand.IntN(len(lastNames))]
-		subject := subjects[mathrand.IntN(len(subjects))]
-		class := classes[mathrand.IntN(len(classes))]
+	columns := []string{"id", "first_name", "last_name", "subject", "class", "email"}
+	return s.insertRows(tx, "teachers", columns, count, func() ([]interface{}, error) {
+		firstName := s.pick(firstNames)
+		lastName := s.pick(lastNames)
+		subject := s.pick(subjects)
+		class := s.pick(classes)
 
 		// Creating a unique email with random suffix
-		randomSuffix := makeRandomString(4)
-		email := fmt.Sprintf("%s.%s.%s@school.edu",
-			strings.ToLower(firstName),
-			strings.ToLower(lastName),
-			randomSuffix)
-
-		// Create UUID for ID
-		id := uuid.New().String()
-
-		_, err := stmt.Exec(id, firstName, lastName, subject, class, email)
+		email, _, err := s.unique(func(suffix string) string {
+			return fmt.Sprintf("%s.%s.%s@school.edu", strings.ToLower(firstName), strings.ToLower(lastName), suffix)
+		})
 		if err != nil {
-			// Just log and continue if there's an error (likely duplicate email)
-			log.Printf("Error inserting teacher #%d: %v", i+1, err)
-			i-- // retry
-			continue
+			return nil, err
 		}
 
-		if (i+1)%100 == 0 {
-			fmt.Printf("  %d teacher records generated\n", i+1)
-		}
-	}
+		return []interface{}{s.uuid(), firstName, lastName, subject, class, email}, nil
+	}, nil)
 }
 
 // generateStudents creates and inserts student records
-func generateStudents(db *sql.DB, count int) {
-	stmt, err := db.Prepare(`
-		INSERT INTO students (id, first_name, last_name, class, email)
-		VALUES (?, ?, ?, ?, ?)
-	`)
-	if err != nil {
-		log.Fatalf("Failed to prepare students statement: %v", err)
-	}
-	defer stmt.Close()
-
+func (s *seeder) generateStudents(tx *sql.Tx, count int) error {
 	fmt.Printf("Generating %d student records...\n", count)
 
-	for i := 0; i < count; i++ {
-		firstName := firstNames[mathrand.IntN(len(firstNames))]
-		lastName := lastNames[mathrand.IntN(len(lastNames))]
-		class := classes[mathrand.IntN(len(classes))]
+	columns := []string{"id", "first_name", "last_name", "class", "email"}
+	return s.insertRows(tx, "students", columns, count, func() ([]interface{}, error) {
+		firstName := s.pick(firstNames)
+		lastName := s.pick(lastNames)
+		class := s.pick(classes)
 
 		// Creating a unique email with random suffix
-		randomSuffix := makeRandomString(4)
-		email := fmt.Sprintf("student.%s.%s.%s@school.edu",
-			strings.ToLower(firstName),
-			strings.ToLower(lastName),
-			randomSuffix)
-
-		// Create UUID for ID
-		id := uuid.New().String()
-
-		_, err := stmt.Exec(id, firstName, lastName, class, email)
+		email, _, err := s.unique(func(suffix string) string {
+			return fmt.Sprintf("student.%s.%s.%s@school.edu", strings.ToLower(firstName), strings.ToLower(lastName), suffix)
+		})
 		if err != nil {
-			// Just log and continue if there's an error (likely duplicate email)
-			log.Printf("Error inserting student #%d: %v", i+1, err)
-			i-- // retry
-			continue
+			return nil, err
 		}
 
-		if (i+1)%100 == 0 {
-			fmt.Printf("  %d student records generated\n", i+1)
-		}
-	}
+		return []interface{}{s.uuid(), firstName, lastName, class, email}, nil
+	}, nil)
 }
 
-// generateExecutives creates and inserts executive records
-func generateExecutives(db *sql.DB, count int) {
-	stmt, err := db.Prepare(`
-		INSERT INTO executives (
-			id, first_name, last_name, email, username, password, 
-			last_password_change, user_creation_time, role, user_inactive
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`)
-	if err != nil {
-		log.Fatalf("Failed to prepare executives statement: %v", err)
-	}
-	defer stmt.Close()
-
+// generateExecutives creates and inserts executive records and returns their
+// plaintext credentials
+func (s *seeder) generateExecutives(tx *sql.Tx, count int) ([]credential, error) {
 	fmt.Printf("Generating %d executive records...\n", count)
 
-	for i := 0; i < count; i++ {
-		firstName := firstNames[mathrand.IntN(len(firstNames))]
-		lastName := lastNames[mathrand.IntN(len(lastNames))]
-		role := roles[mathrand.IntN(len(roles))]
+	columns := []string{
+		"id", "first_name", "last_name", "email", "username", "password",
+		"last_password_change", "user_creation_time", "role", "user_inactive",
+	}
 
-		// Creating a unique username and email with random suffix
-		randomSuffix := makeRandomString(4)
-		username := fmt.Sprintf("%s_%s_%s",
-			strings.ToLower(firstName),
-			strings.ToLower(lastName),
-			randomSuffix)
+	pending := make(map[string]string)
+	credentials := make([]credential, 0, count)
 
-		email := fmt.Sprintf("exec.%s.%s.%s@school.edu",
-			strings.ToLower(firstName),
-			strings.ToLower(lastName),
-			randomSuffix)
+	err := s.insertRows(tx, "executives", columns, count, func() ([]interface{}, error) {
+		firstName := s.pick(firstNames)
+		lastName := s.pick(lastNames)
+		role := s.pick(roles)
 
-		// Create UUID for ID
-		id := uuid.New().String()
+		// Creating a unique username and email with random suffix
+		username, suffix, err := s.unique(func(suffix string) string {
+			return fmt.Sprintf("%s_%s_%s", strings.ToLower(firstName), strings.ToLower(lastName), suffix)
+		})
+		if err != nil {
+			return nil, err
+		}
+		email := fmt.Sprintf("exec.%s.%s.%s@school.edu", strings.ToLower(firstName), strings.ToLower(lastName), suffix)
 
 		// Create a password and hash it with bcrypt
-		rawPassword := fmt.Sprintf("Pass%s!", makeRandomString(8))
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(rawPassword), bcrypt.DefaultCost)
+		rawPassword := fmt.Sprintf("Pass%s!", s.randomString(8))
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(rawPassword), s.bcryptCost)
 		if err != nil {
-			log.Fatalf("Failed to hash password: %v", err)
+			return nil, fmt.Errorf("failed to hash password: %v", err)
 		}
+		pending[username] = rawPassword
 
 		// Create random timestamps for last year
-		now := time.Now()
-		creationTime := now.Add(-time.Duration(mathrand.IntN(365)) * 24 * time.Hour)
-		passwordChangeTime := creationTime.Add(time.Duration(mathrand.IntN(int(now.Sub(creationTime).Hours()/24))) * 24 * time.Hour)
+		creationTime := s.now.Add(-time.Duration(s.rng.IntN(365)) * 24 * time.Hour)
+		passwordChangeTime := creationTime.Add(time.Duration(s.rng.IntN(int(s.now.Sub(creationTime).Hours()/24)+1)) * 24 * time.Hour)
 
 		// Random boolean for user_inactive (mostly active)
-		inactive := mathrand.IntN(10) == 0 // 10% chance of being inactive
+		inactive := s.rng.IntN(10) == 0 // 10% chance of being inactive
 
-		_, err = stmt.Exec(
-			id, firstName, lastName, email, username, hashedPassword,
+		return []interface{}{
+			s.uuid(), firstName, lastName, email, username, hashedPassword,
 			passwordChangeTime, creationTime, role, inactive,
-		)
-		if err != nil {
-			// Just log and continue if there's an error (likely duplicate email/username)
-			log.Printf("Error inserting executive #%d: %v", i+1, err)
-			i-- // retry
-			continue
+		}, nil
+	}, func(row []interface{}) {
+		username := row[4].(string)
+		credentials = append(credentials, credential{username: username, password: pending[username]})
+		delete(pending, username)
+	})
+
+	return credentials, err
+}
+
+// insertRows inserts count generated rows using multi-row INSERTs of up to
+// batchSize rows. A batch that hits a duplicate key is rolled back to a
+// savepoint and regenerated, up to maxRetries attempts. inserted, if not nil,
+// is called for every row that made it into the table.
+func (s *seeder) insertRows(tx *sql.Tx, table string, columns []string, count int, generate func() ([]interface{}, error), inserted func(row []interface{})) error {
+	batchSize := min(s.batchSize, maxPlaceholders/len(columns))
+	rowPlaceholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ") + ")"
+
+	for done := 0; done < count; {
+		size := min(batchSize, count-done)
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+			table, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat(rowPlaceholder+", ", size), ", "))
+
+		var rows [][]interface{}
+		for attempt := 1; ; attempt++ {
+			rows = make([][]interface{}, 0, size)
+			args := make([]interface{}, 0, size*len(columns))
+			for i := 0; i < size; i++ {
+				row, err := generate()
+				if err != nil {
+					return err
+				}
+				rows = append(rows, row)
+				args = append(args, row...)
+			}
+
+			if _, err := tx.Exec("SAVEPOINT seed_batch"); err != nil {
+				return err
+			}
+			_, err := tx.Exec(query, args...)
+			if err == nil {
+				if _, err := tx.Exec("RELEASE SAVEPOINT seed_batch"); err != nil {
+					return err
+				}
+				break
+			}
+
+			var mysqlErr *driver.MySQLError
+			if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1062 || attempt >= s.maxRetries {
+				return fmt.Errorf("inserting %s rows %d-%d (attempt %d): %v", table, done+1, done+size, attempt, err)
+			}
+
+			// Likely a duplicate email/username already in the table
+			log.Printf("Duplicate key inserting %s rows %d-%d, retrying: %v", table, done+1, done+size, err)
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT seed_batch"); err != nil {
+				return err
+			}
 		}
 
-		if (i+1)%100 == 0 {
-			fmt.Printf("  %d executive records generated\n", i+1)
+		if inserted != nil {
+			for _, row := range rows {
+				inserted(row)
+			}
+		}
+
+		done += size
+		if done%100 == 0 || done == count {
+			fmt.Printf("  %d %s records generated\n", done, table)
 		}
 	}
+
+	return nil
 }
 
-// makeRandomString generates a random string of specified length
-func makeRandomString(length int) string {
-	randomBytes := make([]byte, length/2) // Each byte becomes 2 hex chars
-	_, err := rand.Read(randomBytes)
+// unique builds a value from a random suffix until it produces one not
+// generated before in this run, returning the value and the suffix used
+func (s *seeder) unique(build func(suffix string) string) (string, string, error) {
+	for attempt := 0; attempt < s.maxRetries; attempt++ {
+		suffix := s.randomString(4)
+		value := build(suffix)
+		if !s.used[value] {
+			s.used[value] = true
+			return value, suffix, nil
+		}
+	}
+	return "", "", fmt.Errorf("could not generate a unique value after %d attempts", s.maxRetries)
+}
+
+func (s *seeder) pick(options []string) string {
+	return options[s.rng.IntN(len(options))]
+}
+
+// uuid returns a version 4 UUID drawn from the seeded generator
+func (s *seeder) uuid() string {
+	id, err := uuid.NewRandomFromReader(rngReader{s.rng})
 	if err != nil {
-		log.Fatalf("Failed to generate random string: %v", err)
+		log.Fatalf("Failed to generate UUID: %v", err)
 	}
+	return id.String()
+}
+
+// randomString generates a random hex string of specified length
+func (s *seeder) randomString(length int) string {
+	randomBytes := make([]byte, length/2) // Each byte becomes 2 hex chars
+	rngReader{s.rng}.Read(randomBytes)
 	return hex.EncodeToString(randomBytes)
 }
+
+// rngReader adapts a math/rand generator to io.Reader
+type rngReader struct {
+	rng *mathrand.Rand
+}
+
+func (r rngReader) Read(p []byte) (int, error) {
+	var buf [8]byte
+	for i := 0; i < len(p); i += len(buf) {
+		binary.LittleEndian.PutUint64(buf[:], r.rng.Uint64())
+		copy(p[i:], buf[:])
+	}
+	return len(p), nil
+}
+
+func writeCredentials(path string, credentials []credential) error {
+	var b strings.Builder
+	b.WriteString("username,password\n")
+	for _, c := range credentials {
+		fmt.Fprintf(&b, "%s,%s\n", c.username, c.password)
+	}
+	return os.WriteFile(path, []byte(b.String()), 0o600)
+}
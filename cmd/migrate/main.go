@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"go-rest-api/internal/migrate"
+	"go-rest-api/internal/repository/sqlconnect"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const usage = `Usage: migrate [-dir db/migrations] <command>
+
+Commands:
+  up             Apply all pending migrations
+  down           Roll back the most recent migration
+  redo           Roll back and re-apply the most recent migration
+  status         List migrations and whether they are applied
+  create <name>  Create a new empty migration
+  baseline <version>
+                 Record migrations up to and including version as applied
+                 without running them, for a database whose schema was
+                 created by hand, e.g. baseline 20250430071253
+`
+
+func main() {
+	dir := flag.String("dir", "db/migrations", "directory containing the migration files")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+
+	if command == "create" {
+		if flag.NArg() < 2 {
+			log.Fatalln("create requires a migration name")
+		}
+		path, err := migrate.Create(*dir, strings.Join(flag.Args()[1:], " "))
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		fmt.Println("Created", path)
+		return
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		fmt.Println("Error loading .env file", err)
+	}
+
+	db, err := sqlconnect.ConnectDb()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer db.Close()
+
+	m := migrate.New(db, *dir)
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		err = m.Up(ctx)
+	case "down":
+		err = m.Down(ctx)
+	case "redo":
+		err = m.Redo(ctx)
+	case "status":
+		err = m.Status(ctx)
+	case "baseline":
+		if flag.NArg() < 2 {
+			log.Fatalln("baseline requires a migration version")
+		}
+		version, parseErr := strconv.ParseInt(flag.Arg(1), 10, 64)
+		if parseErr != nil {
+			log.Fatalf("Invalid migration version %q", flag.Arg(1))
+		}
+		err = m.Baseline(ctx, version)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("migrate %s: %v", command, err)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	"go-rest-api/internal/audit"
+	mysqlrepo "go-rest-api/internal/repository/mysql"
+	"go-rest-api/internal/repository/sqlconnect"
+	"log"
+	"os"
+	"time"
+)
+
+const usage = `Usage: purge [-older-than 720h]
+
+Permanently deletes the teachers, students and execs that were soft deleted
+longer ago than the retention window. It connects with the database
+credentials from the environment, so only administrators can run it.
+`
+
+func main() {
+	olderThan := flag.Duration("older-than", 30*24*time.Hour, "retention window for soft deleted rows")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 0 || *olderThan < 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	err := godotenv.Load()
+	if err != nil {
+		fmt.Println("Error loading .env file", err)
+	}
+
+	db, err := sqlconnect.ConnectDb()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer db.Close()
+
+	ctx := audit.WithActor(context.Background(), "purge command")
+	purgers := []struct {
+		name  string
+		purge func(context.Context, time.Duration) (int, error)
+	}{
+		{"teachers", mysqlrepo.NewTeacherRepository(db).Purge},
+		{"students", mysqlrepo.NewStudentRepository(db).Purge},
+		{"execs", mysqlrepo.NewExecRepository(db).Purge},
+	}
+	for _, p := range purgers {
+		purged, err := p.purge(ctx, *olderThan)
+		if err != nil {
+			log.Fatalf("purge %s: %v", p.name, err)
+		}
+		fmt.Printf("Purged %d %s deleted more than %s ago\n", purged, p.name, *olderThan)
+	}
+}
@@ -1,10 +1,8 @@
 -- +goose Up
-CREATE DATABASE IF NOT EXISTS school;
-
-USE school;
-
--- Enable the UUID function for MySQL
-SET @@global.sql_mode = 'STRICT_TRANS_TABLES';
+-- The tables are created in the database of the connection, DB_NAME. The
+-- API expects strict mode (STRICT_TRANS_TABLES), the MySQL default since
+-- 5.7; if the server has it turned off, enable it in the server
+-- configuration, since SET GLOBAL needs privileges the app user lacks.
 
 -- Table: teachers
 CREATE TABLE teachers (
@@ -40,3 +38,8 @@ CREATE TABLE executives (
                             role VARCHAR(255) NOT NULL,
                             user_inactive BOOLEAN DEFAULT FALSE
 );
+
+-- +goose Down
+DROP TABLE IF EXISTS executives;
+DROP TABLE IF EXISTS students;
+DROP TABLE IF EXISTS teachers;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- Lookup tables for the class and subject columns of teachers and students.
+-- Names are the primary key so the existing columns can reference them
+-- directly.
+CREATE TABLE classes (
+                         name VARCHAR(255) PRIMARY KEY
+);
+
+CREATE TABLE subjects (
+                          name VARCHAR(255) PRIMARY KEY
+);
+
+INSERT INTO classes (name) VALUES
+    ('1A'), ('1B'), ('1C'), ('2A'), ('2B'), ('2C'), ('3A'), ('3B'), ('3C'),
+    ('4A'), ('4B'), ('4C'), ('5A'), ('5B'), ('5C'), ('6A'), ('6B'), ('6C');
+
+INSERT INTO subjects (name) VALUES
+    ('Mathematics'), ('Physics'), ('Chemistry'), ('Biology'), ('History'),
+    ('Geography'), ('English'), ('Literature'), ('Computer Science'), ('Art'),
+    ('Music'), ('Physical Education'), ('Economics'), ('Psychology'), ('Philosophy');
+
+-- +goose Down
+DROP TABLE IF EXISTS subjects;
+DROP TABLE IF EXISTS classes;
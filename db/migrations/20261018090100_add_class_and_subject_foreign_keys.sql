@@ -0,0 +1,47 @@
+-- +goose Up
+-- Backfill: normalise existing free-text values onto the lookup tables before
+-- the foreign keys are added.
+
+-- Stray whitespace and case differences, e.g. " mathematics"
+UPDATE teachers t JOIN subjects s ON LOWER(TRIM(t.subject)) = LOWER(s.name)
+SET t.subject = s.name
+WHERE t.subject <> BINARY s.name;
+
+UPDATE teachers t JOIN classes c ON UPPER(TRIM(t.class)) = UPPER(c.name)
+SET t.class = c.name
+WHERE t.class <> BINARY c.name;
+
+UPDATE students s JOIN classes c ON UPPER(TRIM(s.class)) = UPPER(c.name)
+SET s.class = c.name
+WHERE s.class <> BINARY c.name;
+
+-- Common abbreviations of the standard subjects
+UPDATE teachers SET subject = 'Mathematics' WHERE TRIM(subject) IN ('Maths', 'Math');
+UPDATE teachers SET subject = 'Physical Education' WHERE TRIM(subject) IN ('PE', 'P.E.', 'Phys Ed');
+UPDATE teachers SET subject = 'Computer Science' WHERE TRIM(subject) IN ('CS', 'Comp Sci', 'Computing');
+UPDATE teachers SET subject = 'English' WHERE TRIM(subject) = 'English Language';
+UPDATE teachers SET subject = 'Literature' WHERE TRIM(subject) = 'English Literature';
+
+-- Anything left is kept as a class or subject of its own rather than failing
+-- the migration; rename or merge them through the API afterwards.
+INSERT IGNORE INTO subjects (name)
+SELECT DISTINCT subject FROM teachers;
+
+INSERT IGNORE INTO classes (name)
+SELECT DISTINCT class FROM teachers
+UNION
+SELECT DISTINCT class FROM students;
+
+ALTER TABLE teachers
+    ADD CONSTRAINT fk_teachers_class FOREIGN KEY (class) REFERENCES classes (name) ON UPDATE CASCADE,
+    ADD CONSTRAINT fk_teachers_subject FOREIGN KEY (subject) REFERENCES subjects (name) ON UPDATE CASCADE;
+
+ALTER TABLE students
+    ADD CONSTRAINT fk_students_class FOREIGN KEY (class) REFERENCES classes (name) ON UPDATE CASCADE;
+
+-- +goose Down
+ALTER TABLE students DROP FOREIGN KEY fk_students_class;
+ALTER TABLE students DROP INDEX fk_students_class;
+
+ALTER TABLE teachers DROP FOREIGN KEY fk_teachers_subject, DROP FOREIGN KEY fk_teachers_class;
+ALTER TABLE teachers DROP INDEX fk_teachers_subject, DROP INDEX fk_teachers_class;
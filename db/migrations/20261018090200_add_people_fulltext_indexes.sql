@@ -0,0 +1,10 @@
+-- +goose Up
+-- Full-text indexes on names and emails for GET /search
+ALTER TABLE teachers ADD FULLTEXT INDEX ft_teachers_search (first_name, last_name, email);
+ALTER TABLE students ADD FULLTEXT INDEX ft_students_search (first_name, last_name, email);
+ALTER TABLE executives ADD FULLTEXT INDEX ft_executives_search (first_name, last_name, email);
+
+-- +goose Down
+ALTER TABLE executives DROP INDEX ft_executives_search;
+ALTER TABLE students DROP INDEX ft_students_search;
+ALTER TABLE teachers DROP INDEX ft_teachers_search;
@@ -0,0 +1,9 @@
+-- +goose Up
+-- Optimistic locking: version is incremented by every update and served as
+-- the ETag of GET /teachers/{id}
+ALTER TABLE teachers
+    ADD COLUMN version INT UNSIGNED NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE teachers DROP COLUMN updated_at, DROP COLUMN version;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- Soft delete: DELETE sets deleted_at and the row is hidden until restored
+-- or purged. Unique emails and usernames stay taken meanwhile.
+ALTER TABLE teachers
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD INDEX idx_teachers_deleted_at (deleted_at);
+ALTER TABLE students
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD INDEX idx_students_deleted_at (deleted_at);
+ALTER TABLE executives
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD INDEX idx_executives_deleted_at (deleted_at);
+
+-- +goose Down
+ALTER TABLE executives DROP INDEX idx_executives_deleted_at, DROP COLUMN deleted_at;
+ALTER TABLE students DROP INDEX idx_students_deleted_at, DROP COLUMN deleted_at;
+ALTER TABLE teachers DROP INDEX idx_teachers_deleted_at, DROP COLUMN deleted_at;
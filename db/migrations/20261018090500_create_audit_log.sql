@@ -0,0 +1,19 @@
+-- +goose Up
+-- One row per create, update, delete, restore or purge of a teacher, student
+-- or exec, written in the same transaction as the change. changes maps each
+-- field that changed to its old and new value.
+CREATE TABLE audit_log (
+    id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+    actor VARCHAR(255) NOT NULL,
+    resource VARCHAR(32) NOT NULL,
+    resource_id CHAR(36) NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    changes JSON NOT NULL,
+    request_id VARCHAR(128) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_log_resource (resource, resource_id),
+    INDEX idx_audit_log_created_at (created_at)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/repository"
+	"net/http"
+)
+
+// auditSelectable are the fields a sparse fieldset may name
+var auditSelectable = map[string]bool{
+	"id":          true,
+	"actor":       true,
+	"resource":    true,
+	"resource_id": true,
+	"action":      true,
+	"changes":     true,
+	"request_id":  true,
+	"created_at":  true,
+}
+
+// auditFields are the columns that can be used for filtering and sorting
+var auditFields = map[string]fieldKind{
+	"actor":       textField,
+	"resource":    textField,
+	"resource_id": textField,
+	"action":      textField,
+	"request_id":  textField,
+	"created_at":  timeField,
+}
+
+type AuditHandler struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditHandler(repo repository.AuditRepository) *AuditHandler {
+	return &AuditHandler{repo: repo}
+}
+
+// List handles GET /audit with pagination, filters and sorting, oldest entry
+// first unless sorted otherwise. id is short for resource_id, so
+// /audit?resource=teachers&id=... is the history of one teacher.
+func (h *AuditHandler) List(w http.ResponseWriter, r *http.Request) {
+	// The defaults are written into the query so that cursors and Link
+	// headers carry them
+	query := r.URL.Query()
+	if query.Has("id") && !query.Has("resource_id") {
+		query["resource_id"] = query["id"]
+		query.Del("id")
+	}
+	if !query.Has("sort_by") {
+		query.Set("sort_by", "created_at:asc")
+	}
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = query.Encode()
+
+	opts, err := parseListOptions(r, auditFields)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fields, err := parseFields(r, auditSelectable)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	auditPage, err := fetchPage(r.Context(), opts, fields, h.repo.List)
+	if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	writePage(w, r, opts, auditPage)
+}
@@ -0,0 +1,37 @@
+package handlers_test
+
+import (
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestAuditListOldestFirst(t *testing.T) {
+	audit := memory.NewAuditRepository()
+	classes := memory.NewClassRepository()
+	teachers := handlers.NewTeachersHandler(
+		memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), audit),
+		memory.NewStudentRepository(classes, audit),
+	)
+	createTeachers(t, teachers, teacherJSON("Emma", "Stone", "emma@school.test"))
+	createTeachers(t, teachers, teacherJSON("Liam", "Reed", "liam@school.test"))
+	h := handlers.NewAuditHandler(audit)
+
+	w := serve(h.List, http.MethodGet, "/audit?limit=1", "")
+	got := decode[listResponse[models.AuditEntry]](t, w, http.StatusOK)
+	if got.Count != 2 || got.Data[0].Changes["email"].New != "emma@school.test" {
+		t.Fatalf("got %+v, want the first of 2 entries", got)
+	}
+	// The next page keeps the order
+	if link := w.Header().Get("Link"); !strings.Contains(link, "sort_by=created_at%3Aasc") {
+		t.Fatalf("Link = %q, want the default sort", link)
+	}
+
+	w = serve(h.List, http.MethodGet, "/audit?sort_by=created_at:desc&limit=1", "")
+	if link := w.Header().Get("Link"); !strings.Contains(link, "sort_by=created_at%3Adesc") {
+		t.Fatalf("Link = %q, want the requested sort", link)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers_test
+
+import (
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"testing"
+)
+
+func TestBulkChangesAreAllOrNothing(t *testing.T) {
+	classes := memory.NewClassRepository()
+	studentRepo := memory.NewStudentRepository(classes, nil)
+	teachers := handlers.NewTeachersHandler(memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), nil), studentRepo)
+	students := handlers.NewStudentsHandler(studentRepo)
+
+	resources := []struct {
+		name, noun                 string
+		create                     func(t *testing.T) []string
+		get, patchMany, deleteMany http.HandlerFunc
+	}{
+		{
+			name: "teachers", noun: "Teacher",
+			create: func(t *testing.T) []string {
+				created := createTeachers(t, teachers,
+					teacherJSON("Emma", "Stone", "emma@school.test"),
+					teacherJSON("Liam", "Reed", "liam@school.test"))
+				return []string{created[0].ID, created[1].ID}
+			},
+			get: teachers.Get, patchMany: teachers.PatchMany, deleteMany: teachers.DeleteMany,
+		},
+		{
+			name: "students", noun: "Student",
+			create: func(t *testing.T) []string {
+				w := serve(students.Create, http.MethodPost, "/students/", `[`+
+					`{"first_name":"Emma","last_name":"Stone","email":"emma@school.test","class":"`+models.Classes[0]+`"},`+
+					`{"first_name":"Liam","last_name":"Reed","email":"liam@school.test","class":"`+models.Classes[0]+`"}]`)
+				created := decode[listResponse[models.Student]](t, w, http.StatusCreated).Data
+				return []string{created[0].ID, created[1].ID}
+			},
+			get: students.Get, patchMany: students.PatchMany, deleteMany: students.DeleteMany,
+		},
+	}
+
+	for _, res := range resources {
+		t.Run(res.name, func(t *testing.T) {
+			ids := res.create(t)
+			first := `{"id":"` + ids[0] + `","last_name":"Changed"}`
+
+			tests := []struct {
+				name    string
+				handler http.HandlerFunc
+				method  string
+				body    string
+				status  int
+				detail  string
+				field   string
+			}{
+				{
+					name: "patch conflict", handler: res.patchMany, method: http.MethodPatch,
+					body:   `[` + first + `,{"id":"` + ids[1] + `","email":"EMMA@school.test"}]`,
+					status: http.StatusConflict, detail: "Email already exists (item 1)",
+				},
+				{
+					name: "patch unknown class", handler: res.patchMany, method: http.MethodPatch,
+					body:   `[` + first + `,{"id":"` + ids[1] + `","class":"9Z"}]`,
+					status: http.StatusUnprocessableEntity, field: "[1].class",
+				},
+				{
+					name: "delete missing id", handler: res.deleteMany, method: http.MethodDelete,
+					body:   `["` + ids[0] + `","missing"]`,
+					status: http.StatusNotFound, detail: res.noun + " not found (item 1)",
+				},
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					w := serve(tt.handler, tt.method, "/"+res.name+"/", tt.body)
+					got := decode[problem.Problem](t, w, tt.status)
+					if tt.detail != "" && got.Detail != tt.detail {
+						t.Fatalf("detail = %q, want %q", got.Detail, tt.detail)
+					}
+					if tt.field != "" && (len(got.Errors) != 1 || got.Errors[0].Field != tt.field) {
+						t.Fatalf("errors = %+v, want one on %s", got.Errors, tt.field)
+					}
+
+					w = serve(res.get, http.MethodGet, "/"+res.name+"/"+ids[0], "", pathValue("id", ids[0]))
+					if got := decode[map[string]any](t, w, http.StatusOK); got["last_name"] != "Stone" {
+						t.Fatalf("first item = %v, want it untouched", got)
+					}
+				})
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/validation"
+	"log"
+	"net/http"
+)
+
+type ClassesHandler struct {
+	repo     repository.ClassRepository
+	teachers repository.TeacherRepository
+	students repository.StudentRepository
+}
+
+func NewClassesHandler(repo repository.ClassRepository, teachers repository.TeacherRepository, students repository.StudentRepository) *ClassesHandler {
+	return &ClassesHandler{repo: repo, teachers: teachers, students: students}
+}
+
+// List handles GET /classes/
+func (h *ClassesHandler) List(w http.ResponseWriter, r *http.Request) {
+	classList, err := h.repo.List(r.Context())
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	response := struct {
+		Status string         `json:"status"`
+		Count  int            `json:"count"`
+		Data   []models.Class `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(classList),
+		Data:   classList,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Get handles GET /classes/{class}, summarising the teachers of a class and
+// a page of its students. Page pagination and sorting apply to the
+// students; a cursor is rejected with a 400, because the response has no
+// next_cursor to continue from, and GET /students/?class=... pages through
+// them by cursor instead. At most maxLimit teachers are listed, with teachers_truncated set
+// if there are more than that; teacher_count is always the full number, and
+// GET /teachers/?class=... pages through them all.
+func (h *ClassesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	class, err := h.repo.Get(r.Context(), r.PathValue("class"))
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Class not found")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	opts, err := parseListOptions(r, studentFields)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if opts.Keyset != nil {
+		problem.Error(w, r, http.StatusBadRequest, "cursor pagination is not supported here; use GET /students/?class=...")
+		return
+	}
+	opts.Filters = append(opts.Filters, repository.Eq("class", class.Name))
+
+	studentList, totalStudents, err := h.students.List(r.Context(), opts)
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	teacherList, totalTeachers, err := h.teachers.List(r.Context(), repository.ListOptions{
+		Filters: []repository.Filter{repository.Eq("class", class.Name)},
+		Sort:    []repository.SortField{{Field: "last_name", Order: "asc"}, {Field: "first_name", Order: "asc"}},
+		Limit:   maxLimit,
+	})
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	response := struct {
+		Status string `json:"status"`
+		Data   struct {
+			Class             string           `json:"class"`
+			TeacherCount      int              `json:"teacher_count"`
+			Teachers          []models.Teacher `json:"teachers"`
+			TeachersTruncated bool             `json:"teachers_truncated,omitempty"`
+			StudentCount      int              `json:"student_count"`
+			Students          []models.Student `json:"students"`
+		} `json:"data"`
+	}{Status: "success"}
+	response.Data.Class = class.Name
+	response.Data.TeacherCount = totalTeachers
+	response.Data.Teachers = teacherList
+	response.Data.TeachersTruncated = len(teacherList) < totalTeachers
+	response.Data.StudentCount = totalStudents
+	response.Data.Students = studentList
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Create handles POST /classes/ with an array of classes, stored all or
+// nothing
+func (h *ClassesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var newClasses []models.Class
+	err := json.NewDecoder(r.Body).Decode(&newClasses)
+	if err != nil {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Error parsing JSON")
+		return
+	}
+
+	if errs := validation.Slice(newClasses); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.Create(r.Context(), newClasses)
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Class already exists"+itemSuffix(err))
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Error inserting class")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := struct {
+		Status string         `json:"status"`
+		Count  int            `json:"count"`
+		Data   []models.Class `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(newClasses),
+		Data:   newClasses,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Update handles PUT /classes/{class}, renaming the class. Teachers and
+// students of the class move with it.
+func (h *ClassesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("class")
+
+	var updatedClass models.Class
+	err := json.NewDecoder(r.Body).Decode(&updatedClass)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	if errs := validation.Struct(&updatedClass); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.Rename(r.Context(), name, updatedClass.Name)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Class not found")
+		return
+	} else if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Class already exists")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedClass)
+}
+
+// Delete handles DELETE /classes/{class}. A class with teachers or students
+// can't be deleted.
+func (h *ClassesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("class")
+
+	err := h.repo.Delete(r.Context(), name)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Class not found")
+		return
+	} else if errors.Is(err, repository.ErrInUse) {
+		problem.Error(w, r, http.StatusConflict, "Class still has teachers or students")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to delete data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string `json:"status"`
+		Name   string `json:"name"`
+	}{
+		Status: "success",
+		Name:   name,
+	}
+	json.NewEncoder(w).Encode(response)
+}
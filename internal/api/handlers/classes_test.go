@@ -0,0 +1,136 @@
+package handlers_test
+
+import (
+	"fmt"
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestClassGet(t *testing.T) {
+	classes := memory.NewClassRepository()
+	teacherRepo := memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), nil)
+	studentRepo := memory.NewStudentRepository(classes, nil)
+	h := handlers.NewClassesHandler(classes, teacherRepo, studentRepo)
+	class := models.Classes[0]
+	createTeachers(t, handlers.NewTeachersHandler(teacherRepo, studentRepo), teacherJSON("Emma", "Stone", "emma@school.test"))
+	createStudents(t, handlers.NewStudentsHandler(studentRepo),
+		studentJSON("Ava", "Lee", "ava@school.test", class),
+		studentJSON("Ben", "Cole", "ben@school.test", class),
+		studentJSON("Dan", "Fox", "dan@school.test", models.Classes[1]))
+
+	type classResponse struct {
+		Data struct {
+			Class        string           `json:"class"`
+			TeacherCount int              `json:"teacher_count"`
+			StudentCount int              `json:"student_count"`
+			Students     []models.Student `json:"students"`
+		} `json:"data"`
+	}
+	w := serve(h.Get, http.MethodGet, "/classes/"+class+"?sort_by=last_name:desc&limit=1", "", pathValue("class", class))
+	got := decode[classResponse](t, w, http.StatusOK).Data
+	if got.Class != class || got.TeacherCount != 1 || got.StudentCount != 2 || !slices.Equal(lastNames(got.Students), []string{"Lee"}) {
+		t.Fatalf("got %+v", got)
+	}
+
+	w = serve(h.Get, http.MethodGet, "/classes/"+class+"?cursor=", "", pathValue("class", class))
+	expectStatus(t, w, http.StatusBadRequest)
+	w = serve(h.Get, http.MethodGet, "/classes/9Z", "", pathValue("class", "9Z"))
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestClassGetSaysTeachersAreCapped(t *testing.T) {
+	classes := memory.NewClassRepository()
+	teacherRepo := memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), nil)
+	studentRepo := memory.NewStudentRepository(classes, nil)
+	teachers := handlers.NewTeachersHandler(teacherRepo, studentRepo)
+	h := handlers.NewClassesHandler(classes, teacherRepo, studentRepo)
+
+	type classResponse struct {
+		Data struct {
+			TeacherCount      int              `json:"teacher_count"`
+			Teachers          []models.Teacher `json:"teachers"`
+			TeachersTruncated bool             `json:"teachers_truncated"`
+		} `json:"data"`
+	}
+	get := func(t *testing.T) classResponse {
+		t.Helper()
+		class := models.Classes[0]
+		w := serve(h.Get, http.MethodGet, "/classes/"+class, "", pathValue("class", class))
+		return decode[classResponse](t, w, http.StatusOK)
+	}
+
+	batch := make([]string, 100)
+	for i := range batch {
+		batch[i] = teacherJSON("Emma", fmt.Sprint("Stone", i), fmt.Sprintf("emma%d@school.test", i))
+	}
+	createTeachers(t, teachers, batch...)
+	if got := get(t).Data; got.TeacherCount != 100 || len(got.Teachers) != 100 || got.TeachersTruncated {
+		t.Fatalf("got %d of %d teachers, truncated %v; want all 100", len(got.Teachers), got.TeacherCount, got.TeachersTruncated)
+	}
+
+	createTeachers(t, teachers, teacherJSON("Liam", "Reed", "liam@school.test"))
+	if got := get(t).Data; got.TeacherCount != 101 || len(got.Teachers) != 100 || !got.TeachersTruncated {
+		t.Fatalf("got %d of %d teachers, truncated %v; want 100 of 101, truncated", len(got.Teachers), got.TeacherCount, got.TeachersTruncated)
+	}
+}
+
+func TestStudentsCreateChecksClass(t *testing.T) {
+	classes := memory.NewClassRepository()
+	h := handlers.NewStudentsHandler(memory.NewStudentRepository(classes, nil))
+
+	w := serve(h.Create, http.MethodPost, "/students/", `[{"first_name":"Ava","last_name":"Lee","email":"ava@school.test","class":"9Z"}]`)
+	expectStatus(t, w, http.StatusUnprocessableEntity)
+
+	w = serve(h.Create, http.MethodPost, "/students/", `[{"first_name":"Ava","last_name":"Lee","email":"ava@school.test","class":"`+models.Classes[0]+`"}]`)
+	if got := decode[listResponse[models.Student]](t, w, http.StatusCreated); got.Count != 1 || got.Data[0].ID == "" {
+		t.Fatalf("got %+v", got)
+	}
+}
+
+func TestClassRenameMovesAndAuditsMembers(t *testing.T) {
+	audit := memory.NewAuditRepository()
+	classes := memory.NewClassRepository()
+	teacherRepo := memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), audit)
+	studentRepo := memory.NewStudentRepository(classes, audit)
+	teachersHandler := handlers.NewTeachersHandler(teacherRepo, studentRepo)
+	studentsHandler := handlers.NewStudentsHandler(studentRepo)
+	classesHandler := handlers.NewClassesHandler(classes, teacherRepo, studentRepo)
+
+	teacher := createTeachers(t, teachersHandler, teacherJSON("Emma", "Stone", "emma@school.test"))[0]
+	w := serve(studentsHandler.Create, http.MethodPost, "/students/", `[{"first_name":"Ava","last_name":"Lee","email":"ava@school.test","class":"`+teacher.Class+`"}]`)
+	student := decode[listResponse[models.Student]](t, w, http.StatusCreated).Data[0]
+
+	w = serve(classesHandler.Update, http.MethodPut, "/classes/"+teacher.Class, `{"name":"7X"}`, pathValue("class", teacher.Class))
+	expectStatus(t, w, http.StatusOK)
+
+	w = serve(teachersHandler.Get, http.MethodGet, "/teachers/"+teacher.ID, "", pathValue("id", teacher.ID))
+	if got := decode[models.Teacher](t, w, http.StatusOK); got.Class != "7X" {
+		t.Fatalf("teacher class = %q, want 7X", got.Class)
+	}
+	// The rename changed the body, so the ETag must change too
+	if etag := w.Header().Get("ETag"); etag != `"2"` {
+		t.Fatalf("ETag = %s, want \"2\"", etag)
+	}
+	w = serve(studentsHandler.Get, http.MethodGet, "/students/"+student.ID, "", pathValue("id", student.ID))
+	if got := decode[models.Student](t, w, http.StatusOK); got.Class != "7X" {
+		t.Fatalf("student class = %q, want 7X", got.Class)
+	}
+
+	entries, _, err := audit.List(t.Context(), repository.ListOptions{Filters: []repository.Filter{repository.Eq("action", models.AuditUpdate)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d update entries, want one each for the teacher and the student", len(entries))
+	}
+	for _, entry := range entries {
+		if change := entry.Changes["class"]; change.Old != teacher.Class || change.New != "7X" {
+			t.Errorf("%s %s: class change = %+v", entry.Resource, entry.ResourceID, change)
+		}
+	}
+}
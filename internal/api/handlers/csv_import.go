@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/validation"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const csvType = "text/csv"
+
+// maxImportRows bounds the data rows of one CSV import
+const maxImportRows = 1000
+
+// importCSV handles a POST of a text/csv body whose header row names the
+// columns, by json name, among the fields in columns. Every row is validated
+// and errors name the line they are on, e.g. "line 3.email". The rows are
+// stored with create, all or nothing, unless ?dry_run=true, in which case
+// check reports what create would do and nothing is written. Either way a
+// rejected import lists every line that conflicts or names an unknown class
+// or subject, found with check. noun names a row in error messages.
+func importCSV[T any](w http.ResponseWriter, r *http.Request, columns map[string]bool, noun string, check func(context.Context, []T) error, create func(context.Context, []T) ([]T, error)) {
+	dryRun := false
+	if query := r.URL.Query(); query.Has("dry_run") {
+		var err error
+		dryRun, err = strconv.ParseBool(query.Get("dry_run"))
+		if err != nil {
+			problem.Error(w, r, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != csvType {
+		problem.Error(w, r, http.StatusUnsupportedMediaType, "Content-Type must be "+csvType)
+		return
+	}
+
+	rows, lines, err := decodeCSV[T](r.Body, columns)
+	if err != nil {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidCSV, err.Error())
+		return
+	}
+
+	var errs []validation.FieldError
+	for i := range rows {
+		for _, fieldErr := range validation.Struct(&rows[i]) {
+			fieldErr.Field = fmt.Sprintf("line %d.%s", lines[i], fieldErr.Field)
+			errs = append(errs, fieldErr)
+		}
+	}
+	if len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	status := http.StatusCreated
+	if dryRun {
+		status = http.StatusOK
+		err = check(r.Context(), rows)
+	} else {
+		var created []T
+		created, err = create(r.Context(), rows)
+		// create stops at the first failing row; check finds the rest
+		var itemErr *repository.ItemError
+		if errors.As(err, &itemErr) {
+			if checkErr := check(r.Context(), rows); checkErr != nil {
+				err = checkErr
+			}
+		}
+		rows = created
+	}
+	if err != nil {
+		writeImportError(w, r, err, lines, noun)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	response := struct {
+		Status string `json:"status"`
+		DryRun bool   `json:"dry_run,omitempty"`
+		Count  int    `json:"count"`
+		Data   []T    `json:"data"`
+	}{
+		Status: "success",
+		DryRun: dryRun,
+		Count:  len(rows),
+		Data:   rows,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// decodeCSV reads the rows of body into the string fields of T tagged with
+// their column names, returning the line each row starts on
+func decodeCSV[T any](body io.Reader, columns map[string]bool) ([]T, []int, error) {
+	reader := csv.NewReader(body)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("CSV header row is required")
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	typ := reflect.TypeFor[T]()
+	fieldIndexes := make([]int, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if !columns[name] {
+			return nil, nil, fmt.Errorf("unknown column %q", name)
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+		fieldIndexes[i] = csvFieldIndex(typ, name)
+	}
+
+	var rows []T
+	var lines []int
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if len(rows) == maxImportRows {
+			return nil, nil, fmt.Errorf("an import is limited to %d rows", maxImportRows)
+		}
+
+		var row T
+		val := reflect.ValueOf(&row).Elem()
+		for i, value := range record {
+			val.Field(fieldIndexes[i]).SetString(strings.TrimSpace(value))
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, row)
+		lines = append(lines, line)
+	}
+	return rows, lines, nil
+}
+
+// csvFieldIndex returns the index of the string field of typ whose json name
+// is name. Import columns must all name one.
+func csvFieldIndex(typ reflect.Type, name string) int {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tagName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if tagName == name && field.Type.Kind() == reflect.String {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("handlers: %s has no string field %q", typ, name))
+}
+
+// writeImportError sends the response for an error of storing or checking
+// imported rows. err may join an *ItemError for each failing row; every one
+// is listed under the line of its row. Conflicts make it a 409, otherwise
+// unknown references make it a 422.
+func writeImportError(w http.ResponseWriter, r *http.Request, err error, lines []int, noun string) {
+	itemErrs := []error{err}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		itemErrs = joined.Unwrap()
+	}
+
+	var conflicts []string
+	var errs []validation.FieldError
+	for _, err := range itemErrs {
+		line := ""
+		var itemErr *repository.ItemError
+		if errors.As(err, &itemErr) && itemErr.Index < len(lines) {
+			line = fmt.Sprintf("line %d", lines[itemErr.Index])
+		}
+		prefix, suffix := "", ""
+		if line != "" {
+			prefix, suffix = line+".", " ("+line+")"
+		}
+
+		var refErr *repository.ReferenceError
+		switch {
+		case errors.As(err, &refErr):
+			errs = append(errs, validation.FieldError{Field: prefix + refErr.Field, Code: "not_allowed", Message: refErr.Error()})
+		case errors.Is(err, repository.ErrConflict):
+			conflicts = append(conflicts, line)
+			errs = append(errs, validation.FieldError{Field: prefix + "email", Code: "conflict", Message: "email already exists"})
+		default:
+			log.Println(err)
+			problem.Error(w, r, http.StatusInternalServerError, "Error inserting "+noun+suffix)
+			return
+		}
+	}
+
+	if len(conflicts) == 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+	detail := "Email already exists"
+	if lines := strings.Join(slices.DeleteFunc(conflicts, func(line string) bool { return line == "" }), ", "); lines != "" {
+		detail += " (" + lines + ")"
+	}
+	p := problem.New(http.StatusConflict, "", detail)
+	p.Errors = errs
+	problem.Write(w, r, p)
+}
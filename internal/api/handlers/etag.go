@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"go-rest-api/internal/api/problem"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// versionETag is the strong entity tag of a version of a record. Each sparse
+// fieldset of it is a different representation, so fields, when not nil,
+// are part of the tag, e.g. "3;email.id" rather than "3".
+func versionETag(version int, fields []string) string {
+	tag := strconv.Itoa(version)
+	if fields != nil {
+		fields = slices.Clone(fields)
+		slices.Sort(fields)
+		tag += ";" + strings.Join(fields, ".")
+	}
+	return `"` + tag + `"`
+}
+
+// etagListMatches reports whether an If-Match or If-None-Match header value
+// is "*" or lists a tag for which match is true. Weak tags are only passed
+// to match when weak is set, as RFC 9110 requires of If-None-Match.
+func etagListMatches(header string, weak bool, match func(tag string) bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == "*" || match(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch evaluates the If-Match header of r, if any, against the
+// current version and writes a 412 problem when it doesn't match. The tag of
+// any fieldset of the current version matches, since an update replaces the
+// whole record either way.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int) bool {
+	header := r.Header.Get("If-Match")
+	current := versionETag(version, nil)
+	if header == "" || etagListMatches(header, false, func(tag string) bool {
+		return tag == current || strings.HasPrefix(tag, strings.TrimSuffix(current, `"`)+";")
+	}) {
+		return true
+	}
+	problem.Error(w, r, http.StatusPreconditionFailed, "If-Match does not match the current ETag "+current)
+	return false
+}
+
+// notModified reports whether the If-None-Match header of r lists etag
+func notModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	return header != "" && etagListMatches(header, true, func(tag string) bool { return tag == etag })
+}
+
+// writeVersionMismatch reports an update that lost a race with another one:
+// 412 when the client made it conditional with If-Match, 409 otherwise
+func writeVersionMismatch(w http.ResponseWriter, r *http.Request, detail string) {
+	if r.Header.Get("If-Match") != "" {
+		problem.Error(w, r, http.StatusPreconditionFailed, detail)
+		return
+	}
+	problem.Error(w, r, http.StatusConflict, detail)
+}
@@ -0,0 +1,64 @@
+package handlers_test
+
+import (
+	"go-rest-api/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestTeachersGetETag(t *testing.T) {
+	h := newTeachersHandler()
+	teacher := createTeachers(t, h, teacherJSON("Emma", "Stone", "emma@school.test"))[0]
+	id := pathValue("id", teacher.ID)
+
+	w := serve(h.Get, http.MethodGet, "/teachers/"+teacher.ID, "", id)
+	if got := decode[models.Teacher](t, w, http.StatusOK); got.Email != "emma@school.test" {
+		t.Fatalf("got %+v", got)
+	}
+	if etag := w.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("ETag = %s, want \"1\"", etag)
+	}
+
+	w = serve(h.Get, http.MethodGet, "/teachers/"+teacher.ID, "", id, header("If-None-Match", `"1"`))
+	expectStatus(t, w, http.StatusNotModified)
+}
+
+func TestTeachersETagNamesTheFieldset(t *testing.T) {
+	h := newTeachersHandler()
+	teacher := createTeachers(t, h, teacherJSON("Emma", "Stone", "emma@school.test"))[0]
+	id := pathValue("id", teacher.ID)
+	target := "/teachers/" + teacher.ID
+
+	tests := []struct {
+		query       string
+		ifNoneMatch string
+		want        string
+		status      int
+	}{
+		{"", "", `"1"`, http.StatusOK},
+		{"?fields=email,id", "", `"1;email.id"`, http.StatusOK},
+		{"?fields=id,email", `"1;email.id"`, `"1;email.id"`, http.StatusNotModified},
+		// A full representation doesn't validate a sparse one, nor the reverse
+		{"?fields=email", `"1"`, `"1;email"`, http.StatusOK},
+		{"", `"1;email"`, `"1"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query+" "+tt.ifNoneMatch, func(t *testing.T) {
+			var opts []option
+			if tt.ifNoneMatch != "" {
+				opts = append(opts, header("If-None-Match", tt.ifNoneMatch))
+			}
+			w := serve(h.Get, http.MethodGet, target+tt.query, "", append(opts, id)...)
+			expectStatus(t, w, tt.status)
+			if etag := w.Header().Get("ETag"); etag != tt.want {
+				t.Fatalf("ETag = %s, want %s", etag, tt.want)
+			}
+		})
+	}
+
+	// Any fieldset's tag of the current version satisfies If-Match
+	w := serve(h.Patch, http.MethodPatch, target, `{"last_name":"Grey"}`, id, header("If-Match", `"1;email"`))
+	expectStatus(t, w, http.StatusOK)
+	w = serve(h.Patch, http.MethodPatch, target, `{"last_name":"Stone"}`, id, header("If-Match", `"1;email"`))
+	expectStatus(t, w, http.StatusPreconditionFailed)
+}
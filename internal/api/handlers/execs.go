@@ -1,26 +1,655 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/auth"
+	"go-rest-api/internal/mail"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/validation"
+	"golang.org/x/crypto/bcrypt"
+	"log"
 	"net/http"
+	"os"
+	"time"
 )
 
-func ExecsHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		w.Write([]byte("Hello GET execs Route"))
-		fmt.Println("Hello GET execs Route")
-	case http.MethodPost:
-		w.Write([]byte("Hello POST execs Route"))
-		fmt.Println("Hello POST execs Route")
-	case http.MethodPut:
-		w.Write([]byte("Hello PUT execs Route"))
-		fmt.Println("Hello PUT execs Route")
-	case http.MethodPatch:
-		w.Write([]byte("Hello PATCH execs Route"))
-		fmt.Println("Hello PATCH execs Route")
-	case http.MethodDelete:
-		w.Write([]byte("Hello DELETE execs Route"))
-		fmt.Println("Hello DELETE execs Route")
+// execSelectable are the fields a sparse fieldset may name
+var execSelectable = map[string]bool{
+	"id":                   true,
+	"first_name":           true,
+	"last_name":            true,
+	"email":                true,
+	"username":             true,
+	"last_password_change": true,
+	"user_creation_time":   true,
+	"role":                 true,
+	"user_inactive":        true,
+	"deleted_at":           true,
+}
+
+// execFields are the columns that can be used for filtering and sorting
+var execFields = map[string]fieldKind{
+	"first_name":           textField,
+	"last_name":            textField,
+	"email":                textField,
+	"username":             textField,
+	"role":                 textField,
+	"last_password_change": timeField,
+	"user_creation_time":   timeField,
+}
+
+// execPatchableFields are the json keys a PATCH may change. The password hash
+// and the timestamps are deliberately absent.
+var execPatchableFields = map[string]bool{
+	"first_name":    true,
+	"last_name":     true,
+	"email":         true,
+	"username":      true,
+	"role":          true,
+	"user_inactive": true,
+}
+
+const defaultResetTokenTTL = 10 * time.Minute
+
+type ExecsHandler struct {
+	repo   repository.ExecRepository
+	mailer mail.Sender
+}
+
+func NewExecsHandler(repo repository.ExecRepository, mailer mail.Sender) *ExecsHandler {
+	return &ExecsHandler{repo: repo, mailer: mailer}
+}
+
+// List handles GET /execs/ with pagination, filters and sorting
+func (h *ExecsHandler) List(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r, execFields)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fields, err := parseFields(r, execSelectable)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	execPage, err := fetchPage(r.Context(), opts, fields, h.repo.List)
+	if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	writePage(w, r, opts, execPage)
+}
+
+// Get handles GET /execs/{id}
+func (h *ExecsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	fields, err := parseFields(r, execSelectable)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
+
+	exec, err := h.repo.Get(r.Context(), idStr, fields...)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Exec not found")
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project(exec, fields))
+}
+
+// Create handles POST /execs/
+func (h *ExecsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var newExecs []models.Exec
+	err := json.NewDecoder(r.Body).Decode(&newExecs)
+	if err != nil {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Error parsing JSON")
+		return
+	}
+
+	errs := validation.Slice(newExecs)
+	for i, newExec := range newExecs {
+		if newExec.Password == "" {
+			errs = append(errs, validation.FieldError{Field: fmt.Sprintf("[%d].password", i), Code: "required", Message: "password is required"})
+		}
+	}
+	if len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	for i, newExec := range newExecs {
+		if !checkRoleChange(w, r, "", "", newExec.Role, fmt.Sprintf(" (item %d)", i)) {
+			return
+		}
+	}
+
+	for i, newExec := range newExecs {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newExec.Password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Println(err)
+			problem.Error(w, r, http.StatusInternalServerError, "Error hashing password")
+			return
+		}
+		newExecs[i].Password = string(hashedPassword)
+	}
+
+	addedExecs, err := h.repo.Create(r.Context(), newExecs)
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email or username already exists"+itemSuffix(err))
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Error inserting exec")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := struct {
+		Status string        `json:"status"`
+		Count  int           `json:"count"`
+		Data   []models.Exec `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(addedExecs),
+		Data:   addedExecs,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// checkRoleChange writes a 403 and returns false unless the caller may
+// change the role of exec id from oldRole to newRole. Nobody may change their
+// own role, and only a Principal may grant or revoke Principal and
+// Administrator. suffix names the item of a bulk request.
+func checkRoleChange(w http.ResponseWriter, r *http.Request, id, oldRole, newRole, suffix string) bool {
+	if oldRole == newRole {
+		return true
+	}
+
+	claims, ok := auth.FromContext(r.Context())
+	switch {
+	case !ok:
+		problem.Error(w, r, http.StatusForbidden, "Changing a role requires an authenticated caller"+suffix)
+	case id != "" && claims.Subject == id:
+		problem.Error(w, r, http.StatusForbidden, "You cannot change your own role"+suffix)
+	case !auth.CanChangeRole(claims.Role, oldRole, newRole):
+		problem.Error(w, r, http.StatusForbidden, "Only a Principal can grant or revoke the "+auth.RolePrincipal+" and "+auth.RoleAdministrator+" roles"+suffix)
+	default:
+		return true
+	}
+	return false
+}
+
+// Update handles PUT /execs/{id}
+func (h *ExecsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	var updatedExec models.Exec
+	err := json.NewDecoder(r.Body).Decode(&updatedExec)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	if errs := validation.Struct(&updatedExec); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	existingExec, err := h.repo.Get(r.Context(), idStr)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			problem.Error(w, r, http.StatusNotFound, "Exec not found")
+			return
+		}
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+		return
+	}
+
+	if !checkRoleChange(w, r, existingExec.ID, existingExec.Role, updatedExec.Role, "") {
+		return
+	}
+
+	// The password and timestamps are not replaceable through PUT
+	updatedExec.ID = existingExec.ID
+	updatedExec.Password = ""
+	updatedExec.LastPasswordChange = existingExec.LastPasswordChange
+	updatedExec.UserCreationTime = existingExec.UserCreationTime
+
+	err = h.repo.Update(r.Context(), updatedExec)
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email or username already exists")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedExec)
+}
+
+// Patch handles PATCH /execs/{id} with a merge patch or JSON Patch body
+func (h *ExecsHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	updates, ok := decodePatch(w, r)
+	if !ok {
+		return
+	}
+
+	existingExec, err := h.repo.Get(r.Context(), idStr)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			problem.Error(w, r, http.StatusNotFound, "Exec not found")
+			return
+		}
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+		return
+	}
+
+	oldRole := existingExec.Role
+	if err := patchStruct(&existingExec, updates, execPatchableFields); err != nil {
+		writePatchError(w, r, err)
+		return
+	}
+	if !checkRoleChange(w, r, existingExec.ID, oldRole, existingExec.Role, "") {
+		return
+	}
+
+	if errs := validation.Struct(&existingExec); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.Update(r.Context(), existingExec)
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email or username already exists")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(existingExec)
+}
+
+// PatchMany handles PATCH /execs/, applying an array of merge patches with
+// an "id" member each in one transaction. Nothing is changed if
+// any id is missing.
+func (h *ExecsHandler) PatchMany(w http.ResponseWriter, r *http.Request) {
+	if _, ok := patchMediaType(w, r, mergePatchType); !ok {
+		return
+	}
+
+	var updates []mergePatch
+	err := json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	execs := make([]models.Exec, 0, len(updates))
+	for i, update := range updates {
+		id, _ := update["id"].(string)
+		if id == "" {
+			problem.Error(w, r, http.StatusBadRequest, fmt.Sprintf("id is required (item %d)", i))
+			return
+		}
+
+		existingExec, err := h.repo.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				problem.Error(w, r, http.StatusNotFound, fmt.Sprintf("Exec not found (item %d)", i))
+				return
+			}
+			log.Println(err)
+			problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+			return
+		}
+
+		oldRole := existingExec.Role
+		if err := patchStruct(&existingExec, update, execPatchableFields); err != nil {
+			writePatchError(w, r, &repository.ItemError{Index: i, Err: err})
+			return
+		}
+		if !checkRoleChange(w, r, existingExec.ID, oldRole, existingExec.Role, fmt.Sprintf(" (item %d)", i)) {
+			return
+		}
+		execs = append(execs, existingExec)
+	}
+
+	if errs := validation.Slice(execs); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.UpdateMany(r.Context(), execs)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Exec not found"+itemSuffix(err))
+		return
+	} else if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email or username already exists"+itemSuffix(err))
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string        `json:"status"`
+		Count  int           `json:"count"`
+		Data   []models.Exec `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(execs),
+		Data:   execs,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteMany handles DELETE /execs/, soft deleting a JSON array of ids in one
+// transaction. Nothing is deleted if any id is missing.
+func (h *ExecsHandler) DeleteMany(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	err := json.NewDecoder(r.Body).Decode(&ids)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+	if len(ids) == 0 {
+		problem.Error(w, r, http.StatusBadRequest, "At least one id is required")
+		return
+	}
+
+	err = h.repo.DeleteMany(r.Context(), ids)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Exec not found"+itemSuffix(err))
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to delete data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string   `json:"status"`
+		Count  int      `json:"count"`
+		IDs    []string `json:"ids"`
+	}{
+		Status: "success",
+		Count:  len(ids),
+		IDs:    ids,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Delete handles DELETE /execs/{id}, soft deleting the record
+func (h *ExecsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	err := h.repo.Delete(r.Context(), idStr)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Exec not found")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to delete data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string `json:"status"`
+		ID     string `json:"id"`
+	}{
+		Status: "success",
+		ID:     idStr,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Restore handles POST /execs/{id}/restore, undoing a soft delete
+func (h *ExecsHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	err := h.repo.Restore(r.Context(), idStr)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Deleted exec not found")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to restore data")
+		return
+	}
+
+	exec, err := h.repo.Get(r.Context(), idStr)
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exec)
+}
+
+// Login handles POST /execs/login
+func (h *ExecsHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	if req.Username == "" || req.Password == "" {
+		problem.Error(w, r, http.StatusBadRequest, "Username and password are required")
+		return
+	}
+
+	exec, err := h.repo.GetByUsername(r.Context(), req.Username)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusUnauthorized, "Invalid username or password")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(exec.Password), []byte(req.Password)) != nil {
+		problem.Error(w, r, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+
+	if exec.UserInactive {
+		problem.Error(w, r, http.StatusForbidden, "Account is inactive")
+		return
+	}
+
+	token, err := auth.SignToken(exec.ID, exec.Username, exec.Role)
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to create token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string `json:"status"`
+		Token  string `json:"token"`
+	}{
+		Status: "success",
+		Token:  token,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// ForgotPassword handles POST /execs/forgotpassword
+func (h *ExecsHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email string `json:"email"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil || req.Email == "" {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	ttl := defaultResetTokenTTL
+	if v := os.Getenv("RESET_TOKEN_EXPIRES_IN"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil {
+			log.Println(err)
+			problem.Error(w, r, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+	}
+
+	// The same response is sent whether or not the email exists so the
+	// endpoint can't be used to discover accounts
+	response := struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{
+		Status:  "success",
+		Message: "If the email is registered, a password reset link has been sent",
+	}
+
+	exec, err := h.repo.GetByEmail(r.Context(), req.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	tokenBytes := make([]byte, 32)
+	_, err = rand.Read(tokenBytes)
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to generate token")
+		return
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	err = h.repo.SetResetToken(r.Context(), exec.ID, hashResetToken(token), ttl)
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	body := fmt.Sprintf(
+		"Use the token below to reset your password. It expires in %s.\n\nPOST /execs/resetpassword/%s",
+		ttl, token,
+	)
+	err = h.mailer.Send(req.Email, "Your password reset token", body)
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to send password reset email")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ResetPassword handles POST /execs/resetpassword/{token}
+func (h *ExecsHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	token := r.PathValue("token")
+	if token == "" {
+		problem.Error(w, r, http.StatusBadRequest, "Reset token is required")
+		return
+	}
+
+	var req struct {
+		NewPassword     string `json:"new_password"`
+		ConfirmPassword string `json:"confirm_password"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	if req.NewPassword == "" {
+		problem.Error(w, r, http.StatusBadRequest, "New password is required")
+		return
+	}
+	if req.NewPassword != req.ConfirmPassword {
+		problem.Error(w, r, http.StatusBadRequest, "Passwords do not match")
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Error hashing password")
+		return
+	}
+
+	err = h.repo.ResetPassword(r.Context(), hashResetToken(token), string(hashedPassword))
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusBadRequest, "Invalid or expired reset token")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+	}{
+		Status:  "success",
+		Message: "Password reset successfully",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// hashResetToken returns the form of a reset token that is stored in the
+// database, so a leaked table can't be used to reset passwords
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
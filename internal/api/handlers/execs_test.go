@@ -0,0 +1,160 @@
+package handlers_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/auth"
+	"go-rest-api/internal/mail"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecRoleChanges(t *testing.T) {
+	repo := memory.NewExecRepository(nil)
+	h := handlers.NewExecsHandler(repo, mail.LogSender{})
+	execs, err := repo.Create(context.Background(), []models.Exec{
+		{FirstName: "Hana", LastName: "Ito", Email: "hana@school.test", Username: "hana", Password: "x", Role: auth.RoleHRManager},
+		{FirstName: "Omar", LastName: "Diaz", Email: "omar@school.test", Username: "omar", Password: "x", Role: auth.RoleCounselor},
+		{FirstName: "Pia", LastName: "Berg", Email: "pia@school.test", Username: "pia", Password: "x", Role: auth.RolePrincipal},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hrManager, counselor, principal := execs[0], execs[1], execs[2]
+
+	tests := []struct {
+		name   string
+		caller models.Exec
+		target models.Exec
+		role   string
+		want   int
+	}{
+		{"own role", hrManager, hrManager, auth.RolePrincipal, http.StatusForbidden},
+		{"grant principal", hrManager, counselor, auth.RolePrincipal, http.StatusForbidden},
+		{"grant administrator", hrManager, counselor, auth.RoleAdministrator, http.StatusForbidden},
+		{"revoke principal", hrManager, principal, auth.RoleCounselor, http.StatusForbidden},
+		{"ordinary role", hrManager, counselor, auth.RoleITManager, http.StatusOK},
+		{"principal grants administrator", principal, counselor, auth.RoleAdministrator, http.StatusOK},
+		{"principal on self", principal, principal, auth.RoleCounselor, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.Patch, http.MethodPatch, "/execs/"+tt.target.ID, `{"role":"`+tt.role+`"}`,
+				pathValue("id", tt.target.ID), caller(tt.caller.ID, tt.caller.Role))
+			expectStatus(t, w, tt.want)
+		})
+	}
+}
+
+func TestExecCreatePrivilegedRole(t *testing.T) {
+	h := handlers.NewExecsHandler(memory.NewExecRepository(nil), mail.LogSender{})
+	body := `[{"first_name":"Lee","last_name":"Park","email":"lee@school.test","username":"lee","password":"secret","role":"Administrator"}]`
+
+	expectStatus(t, serve(h.Create, http.MethodPost, "/execs/", body, caller("hr", auth.RoleHRManager)), http.StatusForbidden)
+	expectStatus(t, serve(h.Create, http.MethodPost, "/execs/", body), http.StatusCreated)
+}
+
+// outbox is a mail.Sender that keeps what it is sent
+type outbox []string
+
+func (o *outbox) Send(to, subject, body string) error {
+	*o = append(*o, body)
+	return nil
+}
+
+// resetToken returns the token in the last mail of o
+func (o outbox) resetToken(t *testing.T) string {
+	t.Helper()
+	if len(o) == 0 {
+		t.Fatal("no mail was sent")
+	}
+	_, token, ok := strings.Cut(o[len(o)-1], "/execs/resetpassword/")
+	if !ok {
+		t.Fatalf("no token in %q", o[len(o)-1])
+	}
+	return strings.TrimSpace(token)
+}
+
+func TestExecPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewExecRepository(nil)
+	var mails outbox
+	h := handlers.NewExecsHandler(repo, &mails)
+	execs, err := repo.Create(ctx, []models.Exec{
+		{FirstName: "Hana", LastName: "Ito", Email: "hana@school.test", Username: "hana", Password: "x", Role: auth.RoleCounselor},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exec := execs[0]
+
+	forgot := func(t *testing.T, email string) {
+		t.Helper()
+		w := serve(h.ForgotPassword, http.MethodPost, "/execs/forgotpassword", `{"email":"`+email+`"}`)
+		expectStatus(t, w, http.StatusOK)
+		if !strings.Contains(w.Body.String(), "If the email is registered") {
+			t.Fatalf("got %s, want the generic response", w.Body)
+		}
+	}
+	reset := func(token string) *httptest.ResponseRecorder {
+		return serve(h.ResetPassword, http.MethodPost, "/execs/resetpassword/"+token,
+			`{"new_password":"n3w-secret","confirm_password":"n3w-secret"}`, pathValue("token", token))
+	}
+
+	t.Run("unknown email", func(t *testing.T) {
+		forgot(t, "nobody@school.test")
+		if len(mails) != 0 {
+			t.Fatalf("sent %d mails for an unknown email", len(mails))
+		}
+	})
+
+	t.Run("stores only the hash", func(t *testing.T) {
+		forgot(t, exec.Email)
+		token := mails.resetToken(t)
+		// The raw token doesn't match what is stored; its SHA-256 does
+		if err := repo.ResetPassword(ctx, token, "hash"); err == nil {
+			t.Fatal("the raw token was stored")
+		}
+		sum := sha256.Sum256([]byte(token))
+		if err := repo.ResetPassword(ctx, hex.EncodeToString(sum[:]), "hash"); err != nil {
+			t.Fatalf("the token's SHA-256 doesn't match: %v", err)
+		}
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		t.Setenv("RESET_TOKEN_EXPIRES_IN", "-1m")
+		forgot(t, exec.Email)
+		expectStatus(t, reset(mails.resetToken(t)), http.StatusBadRequest)
+	})
+
+	t.Run("single use and password change time", func(t *testing.T) {
+		before, err := repo.Get(ctx, exec.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Times have a resolution of a second
+		for time.Now().UTC().Format(time.DateTime) == before.LastPasswordChange {
+			time.Sleep(50 * time.Millisecond)
+		}
+
+		forgot(t, exec.Email)
+		token := mails.resetToken(t)
+		expectStatus(t, reset(token), http.StatusOK)
+		expectStatus(t, reset(token), http.StatusBadRequest)
+
+		after, err := repo.Get(ctx, exec.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after.LastPasswordChange <= before.LastPasswordChange {
+			t.Fatalf("last_password_change = %q, want later than %q", after.LastPasswordChange, before.LastPasswordChange)
+		}
+	})
+}
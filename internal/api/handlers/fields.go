@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// parseFields reads the sparse fieldset of ?fields=id,first_name, rejecting
+// names that aren't in allowed. It returns nil, meaning every field, when the
+// parameter is absent or empty.
+func parseFields(r *http.Request, allowed map[string]bool) ([]string, error) {
+	param := r.URL.Query().Get("fields")
+	if param == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(param, ",") {
+		field = strings.TrimSpace(field)
+		if !allowed[field] {
+			return nil, fmt.Errorf("unknown field in fields: %q", field)
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// project returns item unchanged when fields is nil, and otherwise a map of
+// just those fields keyed by json name
+func project(item any, fields []string) interface{} {
+	if fields == nil {
+		return item
+	}
+
+	val := reflect.ValueOf(item)
+	typ := val.Type()
+	projected := make(map[string]interface{}, len(fields))
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if slices.Contains(fields, name) {
+			projected[name] = val.Field(i).Interface()
+		}
+	}
+	return projected
+}
+
+// withKeyFields adds the fields cursor pagination reads from each row, the
+// sort fields and id, to a non-nil fieldset
+func withKeyFields(fields []string, sort []string) []string {
+	if fields == nil {
+		return nil
+	}
+	columns := slices.Clone(fields)
+	for _, field := range append(sort, "id") {
+		if !slices.Contains(columns, field) {
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
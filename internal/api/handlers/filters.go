@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"go-rest-api/internal/repository"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// fieldKind is the type of a list field, which decides the filter operators
+// it accepts
+type fieldKind int
+
+const (
+	textField fieldKind = iota
+	timeField
+)
+
+var kindOperators = map[fieldKind][]repository.Operator{
+	textField: {repository.OpEq, repository.OpNe, repository.OpLike, repository.OpIn, repository.OpNin},
+	timeField: {repository.OpEq, repository.OpNe, repository.OpGt, repository.OpGte, repository.OpLt, repository.OpLte},
+}
+
+// maxFilterValues bounds the comma separated values of an in or nin filter
+const maxFilterValues = 100
+
+// timeLayouts are accepted for time fields, in UTC unless they carry an offset
+var timeLayouts = []string{time.RFC3339, time.DateTime, time.DateOnly}
+
+// parseFilters reads the filters on validFields from the query string:
+//
+//	first_name=Emma          equality
+//	first_name[like]=Em%     any operator accepted by the field's kind
+//	class[in]=1A,1B          comma separated values for in and nin
+//	user_creation_time[gte]=2025-01-01
+//
+// Parameters naming other fields are ignored and empty values are skipped.
+// Time values are normalised to UTC in time.DateTime format.
+func parseFilters(query url.Values, validFields map[string]fieldKind) ([]repository.Filter, error) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var filters []repository.Filter
+	for _, key := range keys {
+		field, op := key, repository.OpEq
+		if name, rest, ok := strings.Cut(key, "["); ok && strings.HasSuffix(rest, "]") {
+			field, op = name, repository.Operator(strings.TrimSuffix(rest, "]"))
+		}
+
+		kind, ok := validFields[field]
+		if !ok {
+			continue
+		}
+		if !slices.Contains(kindOperators[kind], op) {
+			return nil, fmt.Errorf("filter operator %q is not supported on %s", op, field)
+		}
+
+		for _, raw := range query[key] {
+			if raw == "" {
+				continue
+			}
+
+			values := []string{raw}
+			if op == repository.OpIn || op == repository.OpNin {
+				values = strings.Split(raw, ",")
+				if len(values) > maxFilterValues {
+					return nil, fmt.Errorf("%s[%s] accepts at most %d values", field, op, maxFilterValues)
+				}
+			}
+			if kind == timeField {
+				for i, value := range values {
+					normalized, err := parseFilterTime(value)
+					if err != nil {
+						return nil, fmt.Errorf("%s must be a date or RFC 3339 time: %q", field, value)
+					}
+					values[i] = normalized
+				}
+			}
+
+			filters = append(filters, repository.Filter{Field: field, Op: op, Values: values})
+		}
+	}
+	return filters, nil
+}
+
+func parseFilterTime(value string) (string, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC().Format(time.DateTime), nil
+		}
+	}
+	return "", fmt.Errorf("invalid time %q", value)
+}
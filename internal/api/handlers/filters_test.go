@@ -0,0 +1,42 @@
+package handlers_test
+
+import (
+	"fmt"
+	"go-rest-api/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestTeachersListFilters(t *testing.T) {
+	h := newTeachersHandler()
+	createTeachers(t, h,
+		teacherJSON("Emma", "Stone", "emma@school.test"),
+		teacherJSON("Liam", "Reed", "liam@school.test"),
+		teacherJSON("Olivia", "Reed", "olivia@school.test"),
+	)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"first_name=emma", []string{"Emma"}},
+		{"last_name[ne]=reed", []string{"Emma"}},
+		{"first_name[in]=LIAM,olivia&sort_by=first_name:desc", []string{"Olivia", "Liam"}},
+		{"first_name[like]=%25i%25&sort_by=first_name:asc", []string{"Liam", "Olivia"}},
+		{"sort_by=last_name:asc&sort_by=first_name:desc&limit=2", []string{"Olivia", "Liam"}},
+		{"sort_by=first_name:asc&limit=2&page=2", []string{"Olivia"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := serve(h.List, http.MethodGet, "/teachers/?"+tt.query, "")
+			page := decode[listResponse[models.Teacher]](t, w, http.StatusOK)
+			var got []string
+			for _, teacher := range page.Data {
+				got = append(got, teacher.FirstName)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
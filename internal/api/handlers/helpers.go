@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/validation"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxLimit = 100
+
+func isValidSortOrder(order string) bool {
+	return order == "asc" || order == "desc"
+}
+
+func isValidSortField(field string, validFields map[string]fieldKind) bool {
+	_, ok := validFields[field]
+	return ok
+}
+
+// parseListOptions reads page, limit, sort_by, cursor and the filters
+// described at parseFilters from the query string. cursor switches to keyset
+// pagination and takes precedence over page. Only fields in validFields are used
+// for filtering and sorting.
+func parseListOptions(r *http.Request, validFields map[string]fieldKind) (repository.ListOptions, error) {
+	query := r.URL.Query()
+
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit < 1 {
+		limit = 10
+	}
+	if limit > maxLimit {
+		return repository.ListOptions{}, errors.New("limit cannot be greater than 100")
+	}
+
+	filters, err := parseFilters(query, validFields)
+	if err != nil {
+		return repository.ListOptions{}, err
+	}
+
+	opts := repository.ListOptions{
+		Filters: filters,
+		Limit:   limit,
+		Offset:  (page - 1) * limit,
+	}
+
+	// teachers/?include_deleted=true lists soft deleted rows as well
+	if query.Has("include_deleted") {
+		opts.IncludeDeleted, err = strconv.ParseBool(query.Get("include_deleted"))
+		if err != nil {
+			return repository.ListOptions{}, errors.New("include_deleted must be true or false")
+		}
+	}
+
+	// teachers/?sort_by=name:asc&sort_by=class:desc
+	for _, param := range query["sort_by"] {
+		parts := strings.Split(param, ":")
+		if len(parts) != 2 {
+			continue
+		}
+		field, order := parts[0], parts[1]
+		if !isValidSortField(field, validFields) || !isValidSortOrder(order) {
+			continue
+		}
+		opts.Sort = append(opts.Sort, repository.SortField{Field: field, Order: order})
+	}
+
+	// Cursor mode: present but empty starts at the first row
+	if query.Has("cursor") {
+		opts.Keyset, err = decodeCursor(query.Get("cursor"), opts.Sort)
+		if err != nil {
+			return repository.ListOptions{}, err
+		}
+	}
+
+	return opts, nil
+}
+
+// Outcomes of one item of a bulk request in partial mode
+const (
+	bulkCreated  = "created"
+	bulkConflict = "conflict"
+	bulkInvalid  = "invalid"
+	bulkFailed   = "error"
+)
+
+// bulkItemResult is the outcome of one element of a partial bulk request
+type bulkItemResult struct {
+	Index  int                     `json:"index"`
+	Status string                  `json:"status"`
+	Data   interface{}             `json:"data,omitempty"`
+	Error  string                  `json:"error,omitempty"`
+	Errors []validation.FieldError `json:"errors,omitempty"`
+}
+
+func writeMultiStatus(w http.ResponseWriter, count int, results []bulkItemResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusMultiStatus)
+	response := struct {
+		Status  string           `json:"status"`
+		Count   int              `json:"count"`
+		Results []bulkItemResult `json:"results"`
+	}{
+		Status:  "partial",
+		Count:   count,
+		Results: results,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// itemSuffix names the failing element of a bulk operation, if known
+func itemSuffix(err error) string {
+	var itemErr *repository.ItemError
+	if errors.As(err, &itemErr) {
+		return fmt.Sprintf(" (item %d)", itemErr.Index)
+	}
+	return ""
+}
+
+// referenceErrors reports a class or subject that doesn't exist as a field
+// error, prefixed with the element index for bulk operations like
+// validation.Slice does
+func referenceErrors(err error) ([]validation.FieldError, bool) {
+	var refErr *repository.ReferenceError
+	if !errors.As(err, &refErr) {
+		return nil, false
+	}
+
+	field := refErr.Field
+	var itemErr *repository.ItemError
+	if errors.As(err, &itemErr) {
+		field = fmt.Sprintf("[%d].%s", itemErr.Index, field)
+	}
+	return []validation.FieldError{{Field: field, Code: "not_allowed", Message: refErr.Error()}}, true
+}
@@ -0,0 +1,86 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"go-rest-api/internal/auth"
+	"go-rest-api/internal/validation"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// option adjusts a test request before it is served
+type option func(*http.Request)
+
+// pathValue sets a wildcard of the route pattern, as the router would
+func pathValue(name, value string) option {
+	return func(r *http.Request) { r.SetPathValue(name, value) }
+}
+
+func header(name, value string) option {
+	return func(r *http.Request) { r.Header.Set(name, value) }
+}
+
+// caller authenticates the request as an exec with role
+func caller(id, role string) option {
+	return func(r *http.Request) {
+		*r = *r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{Subject: id, Username: id, Role: role}))
+	}
+}
+
+// serve runs handler on a request authenticated as a Principal unless the
+// options say otherwise
+func serve(handler http.HandlerFunc, method, target, body string, opts ...option) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	caller("principal", auth.RolePrincipal)(r)
+	for _, opt := range opts {
+		opt(r)
+	}
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// listResponse is the body of list and bulk responses
+type listResponse[T any] struct {
+	Status     string `json:"status"`
+	Count      int    `json:"count"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+	Data       []T    `json:"data"`
+}
+
+// decode checks the status of w and decodes its body into a T
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder, status int) T {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body)
+	}
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+	return v
+}
+
+// expectStatus fails the test unless w has status
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body)
+	}
+}
+
+// multiStatus is the body of a 207 bulk response
+type multiStatus[T any] struct {
+	Status  string `json:"status"`
+	Count   int    `json:"count"`
+	Results []struct {
+		Index  int                     `json:"index"`
+		Status string                  `json:"status"`
+		Data   T                       `json:"data"`
+		Error  string                  `json:"error"`
+		Errors []validation.FieldError `json:"errors"`
+	} `json:"results"`
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-rest-api/internal/repository"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// cursor is the decoded form of the opaque cursor and next_cursor values.
+// It records the sort it was issued for, so it can't be reused with another.
+type cursor struct {
+	Sort     string   `json:"s"`
+	Values   []string `json:"v"`
+	Backward bool     `json:"b,omitempty"`
+}
+
+var errInvalidCursor = errors.New("cursor is invalid or does not match sort_by")
+
+func sortKey(sort []repository.SortField) string {
+	parts := make([]string, len(sort))
+	for i, s := range sort {
+		parts[i] = s.Field + ":" + s.Order
+	}
+	return strings.Join(parts, ",")
+}
+
+func encodeCursor(sort []repository.SortField, values []string, backward bool) string {
+	data, _ := json.Marshal(cursor{Sort: sortKey(sort), Values: values, Backward: backward})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor turns the cursor query parameter into a keyset. An empty
+// cursor starts at the first row.
+func decodeCursor(value string, sort []repository.SortField) (*repository.Keyset, error) {
+	if value == "" {
+		return &repository.Keyset{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errInvalidCursor
+	}
+	// One value per sort field plus the id
+	if c.Sort != sortKey(sort) || len(c.Values) != len(sort)+1 {
+		return nil, errInvalidCursor
+	}
+	return &repository.Keyset{Values: c.Values, Backward: c.Backward}, nil
+}
+
+// listPage is one page of a list endpoint. total is -1 in cursor mode.
+type listPage[T any] struct {
+	items      []T
+	total      int
+	fields     []string
+	nextCursor string
+	prevCursor string
+}
+
+// fetchPage lists one page with opts, reading only fields if not nil. In
+// cursor mode it asks for an extra row to learn whether there is a further
+// page in the direction of travel, and sets the cursors of the neighbouring
+// pages.
+func fetchPage[T any](ctx context.Context, opts repository.ListOptions, fields []string, list func(context.Context, repository.ListOptions) ([]T, int, error)) (listPage[T], error) {
+	sortFields := make([]string, len(opts.Sort))
+	for i, s := range opts.Sort {
+		sortFields[i] = s.Field
+	}
+	opts.Fields = withKeyFields(fields, sortFields)
+
+	if opts.Keyset == nil {
+		items, total, err := list(ctx, opts)
+		return listPage[T]{items: items, total: total, fields: fields}, err
+	}
+
+	limit := opts.Limit
+	opts.Limit++
+	items, _, err := list(ctx, opts)
+	if err != nil {
+		return listPage[T]{}, err
+	}
+
+	keyset := opts.Keyset
+	more := len(items) > limit
+	if more && keyset.Backward {
+		items = items[1:]
+	} else if more {
+		items = items[:limit]
+	}
+	page := listPage[T]{items: items, total: -1, fields: fields}
+
+	// Going forward from a position there is a previous page and vice versa
+	started := len(keyset.Values) > 0
+	hasNext := more || keyset.Backward
+	hasPrev := (more && keyset.Backward) || (started && !keyset.Backward)
+
+	keysetSort := opts.KeysetSort()
+	if len(items) == 0 {
+		if started && keyset.Backward {
+			page.nextCursor = encodeCursor(opts.Sort, keyset.Values, false)
+		} else if started {
+			page.prevCursor = encodeCursor(opts.Sort, keyset.Values, true)
+		}
+		return page, nil
+	}
+	if hasNext {
+		page.nextCursor = encodeCursor(opts.Sort, keyValues(items[len(items)-1], keysetSort), false)
+	}
+	if hasPrev {
+		page.prevCursor = encodeCursor(opts.Sort, keyValues(items[0], keysetSort), true)
+	}
+	return page, nil
+}
+
+// writePage writes a list response with RFC 8288 next and prev links, limited
+// to the page's fieldset. count is the total number of matches in page mode
+// and the page size in cursor mode, which doesn't count.
+func writePage[T any](w http.ResponseWriter, r *http.Request, opts repository.ListOptions, page listPage[T]) {
+	if links := pageLinks(r, opts, page); len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	count := page.total
+	if count < 0 {
+		count = len(page.items)
+	}
+
+	var data interface{} = page.items
+	if page.fields != nil {
+		projected := make([]interface{}, len(page.items))
+		for i, item := range page.items {
+			projected[i] = project(item, page.fields)
+		}
+		data = projected
+	}
+
+	response := struct {
+		Status     string      `json:"status"`
+		Count      int         `json:"count"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+		PrevCursor string      `json:"prev_cursor,omitempty"`
+		Data       interface{} `json:"data"`
+	}{
+		Status:     "success",
+		Count:      count,
+		NextCursor: page.nextCursor,
+		PrevCursor: page.prevCursor,
+		Data:       data,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+func pageLinks[T any](r *http.Request, opts repository.ListOptions, page listPage[T]) []string {
+	var links []string
+	link := func(rel string, set map[string]string) {
+		query := r.URL.Query()
+		for key, value := range set {
+			query.Set(key, value)
+		}
+		links = append(links, fmt.Sprintf("<%s?%s>; rel=%q", r.URL.Path, query.Encode(), rel))
+	}
+
+	if opts.Keyset != nil {
+		if page.nextCursor != "" {
+			link("next", map[string]string{"cursor": page.nextCursor})
+		}
+		if page.prevCursor != "" {
+			link("prev", map[string]string{"cursor": page.prevCursor})
+		}
+		return links
+	}
+
+	current := opts.Offset/opts.Limit + 1
+	limit := strconv.Itoa(opts.Limit)
+	if opts.Offset+len(page.items) < page.total {
+		link("next", map[string]string{"page": strconv.Itoa(current + 1), "limit": limit})
+	}
+	if current > 1 {
+		link("prev", map[string]string{"page": strconv.Itoa(current - 1), "limit": limit})
+	}
+	return links
+}
+
+// keyValues returns the string forms of item's fields named by sort, found
+// by json name
+func keyValues(item any, sort []repository.SortField) []string {
+	val := reflect.ValueOf(item)
+	typ := val.Type()
+
+	values := make([]string, len(sort))
+	for i, s := range sort {
+		for j := 0; j < typ.NumField(); j++ {
+			name, _, _ := strings.Cut(typ.Field(j).Tag.Get("json"), ",")
+			if name == s.Field {
+				values[i] = fmt.Sprint(val.Field(j).Interface())
+				break
+			}
+		}
+	}
+	return values
+}
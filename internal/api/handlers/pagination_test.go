@@ -0,0 +1,85 @@
+package handlers_test
+
+import (
+	"go-rest-api/internal/models"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+)
+
+func TestTeachersListCursor(t *testing.T) {
+	h := newTeachersHandler()
+	createTeachers(t, h,
+		teacherJSON("Dan", "Reed", "dan@school.test"),
+		teacherJSON("Ava", "Stone", "ava@school.test"),
+		teacherJSON("Eve", "Reed", "eve@school.test"),
+		teacherJSON("Cara", "Lane", "cara@school.test"),
+		teacherJSON("Ben", "Hart", "ben@school.test"),
+	)
+
+	list := func(t *testing.T, query string) listResponse[models.Teacher] {
+		t.Helper()
+		w := serve(h.List, http.MethodGet, "/teachers/?"+query, "")
+		return decode[listResponse[models.Teacher]](t, w, http.StatusOK)
+	}
+	firstNames := func(teachers []models.Teacher) []string {
+		names := []string{}
+		for _, teacher := range teachers {
+			names = append(names, teacher.FirstName)
+		}
+		return names
+	}
+
+	// Each step follows the named cursor of the page before
+	steps := []struct {
+		name     string
+		follow   string
+		want     []string
+		wantNext bool
+		wantPrev bool
+	}{
+		{"first page", "", []string{"Ava", "Ben"}, true, false},
+		{"forward", "next", []string{"Cara", "Dan"}, true, true},
+		{"last page", "next", []string{"Eve"}, false, true},
+		{"backward", "prev", []string{"Cara", "Dan"}, true, true},
+		{"backward to first page", "prev", []string{"Ava", "Ben"}, true, false},
+	}
+
+	var page listResponse[models.Teacher]
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			cursor := ""
+			switch step.follow {
+			case "next":
+				cursor = page.NextCursor
+			case "prev":
+				cursor = page.PrevCursor
+			}
+			page = list(t, "sort_by=first_name:asc&limit=2&cursor="+url.QueryEscape(cursor))
+
+			if got := firstNames(page.Data); !slices.Equal(got, step.want) {
+				t.Fatalf("got %v, want %v", got, step.want)
+			}
+			if (page.NextCursor != "") != step.wantNext {
+				t.Errorf("next_cursor = %q, want one: %v", page.NextCursor, step.wantNext)
+			}
+			if (page.PrevCursor != "") != step.wantPrev {
+				t.Errorf("prev_cursor = %q, want one: %v", page.PrevCursor, step.wantPrev)
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		page := list(t, "first_name=Zoe&sort_by=first_name:asc&cursor=")
+		if len(page.Data) != 0 || page.NextCursor != "" || page.PrevCursor != "" {
+			t.Fatalf("got %+v, want an empty page without cursors", page)
+		}
+	})
+
+	t.Run("cursor for another sort", func(t *testing.T) {
+		first := list(t, "sort_by=first_name:asc&limit=2&cursor=")
+		w := serve(h.List, http.MethodGet, "/teachers/?sort_by=last_name:asc&cursor="+url.QueryEscape(first.NextCursor), "")
+		expectStatus(t, w, http.StatusBadRequest)
+	})
+}
@@ -0,0 +1,320 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/validation"
+	"log"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Media types of PATCH bodies. Plain application/json, or no content type,
+// is read as a merge patch, which is what PATCH bodies always were.
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// errPatchTestFailed is returned when a JSON Patch test operation fails
+var errPatchTestFailed = errors.New("test operation failed")
+
+// patch is a PATCH body that can be applied to the JSON object form of a
+// model
+type patch interface {
+	apply(doc map[string]interface{}) error
+}
+
+// mergePatch is an RFC 7396 JSON Merge Patch. An "id" member is ignored, as
+// bulk bodies use it to name the record.
+type mergePatch map[string]interface{}
+
+func (p mergePatch) apply(doc map[string]interface{}) error {
+	for name, value := range p {
+		if name == "id" {
+			continue
+		}
+		if value == nil {
+			delete(doc, name)
+			continue
+		}
+		doc[name] = mergeValue(doc[name], value)
+	}
+	return nil
+}
+
+// mergeValue merges patch into target as RFC 7396 describes
+func mergeValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	if !ok {
+		targetObj = make(map[string]interface{})
+	}
+	for name, value := range patchObj {
+		if value == nil {
+			delete(targetObj, name)
+		} else {
+			targetObj[name] = mergeValue(targetObj[name], value)
+		}
+	}
+	return targetObj
+}
+
+// jsonPatch is an RFC 6902 JSON Patch limited to the add, remove, replace
+// and test operations. Models are flat, so paths name top-level fields.
+type jsonPatch []patchOperation
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// validate checks the shape of every operation, before any is applied
+func (p jsonPatch) validate() error {
+	for i, op := range p {
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return fmt.Errorf("operation %d: %s requires a value", i, op.Op)
+			}
+		case "remove":
+		default:
+			return fmt.Errorf("operation %d: unsupported op %q", i, op.Op)
+		}
+		if !strings.HasPrefix(op.Path, "/") {
+			return fmt.Errorf("operation %d: path must be a JSON pointer", i)
+		}
+	}
+	return nil
+}
+
+func (p jsonPatch) apply(doc map[string]interface{}) error {
+	for _, op := range p {
+		name := strings.NewReplacer("~1", "/", "~0", "~").Replace(op.Path[1:])
+		if strings.Contains(op.Path[1:], "/") {
+			return patchErrors{{Field: op.Path, Code: "invalid_path", Message: "path must name a top-level field"}}
+		}
+
+		var value interface{}
+		if op.Value != nil {
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return err
+			}
+		}
+
+		current, exists := doc[name]
+		if !exists && op.Op != "add" {
+			return patchErrors{{Field: name, Code: "not_found", Message: "field does not exist"}}
+		}
+
+		switch op.Op {
+		case "add", "replace":
+			doc[name] = value
+		case "remove":
+			delete(doc, name)
+		case "test":
+			if !reflect.DeepEqual(current, value) {
+				return fmt.Errorf("%w: %s", errPatchTestFailed, op.Path)
+			}
+		}
+	}
+	return nil
+}
+
+// patchErrors lists the fields a patch can't set. They are reported as a 422.
+type patchErrors []validation.FieldError
+
+func (e patchErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// readOnlyFields are the model fields no patch may change
+var readOnlyFields = map[string]bool{
+	"id":         true,
+	"deleted_at": true,
+}
+
+// patchStruct applies p to the model target points to. The patch works on
+// the model's JSON object, whose fields must keep their types. Fields a patch
+// removes are reset to their zero value. Changes to readOnlyFields, to
+// unknown fields or, when allowed is not nil, to fields outside allowed are
+// rejected, as is a value of the wrong type; either way target is left
+// untouched.
+func patchStruct(target interface{}, p patch, allowed map[string]bool) error {
+	val := reflect.ValueOf(target).Elem()
+	typ := val.Type()
+
+	fields := make(map[string]int)
+	doc := make(map[string]interface{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" || !typ.Field(i).IsExported() {
+			continue
+		}
+		fields[name] = i
+
+		data, err := json.Marshal(val.Field(i).Interface())
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		doc[name] = value
+	}
+	original := maps.Clone(doc)
+
+	if err := p.apply(doc); err != nil {
+		return err
+	}
+
+	var errs patchErrors
+	for _, name := range slices.Sorted(maps.Keys(doc)) {
+		if _, ok := fields[name]; !ok {
+			errs = append(errs, validation.FieldError{Field: name, Code: "unknown_field", Message: "field does not exist"})
+		}
+	}
+
+	patched := reflect.New(typ).Elem()
+	patched.Set(val)
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		value, present := doc[name]
+		if present && reflect.DeepEqual(value, original[name]) {
+			continue
+		}
+		if readOnlyFields[name] || (allowed != nil && !allowed[name]) {
+			errs = append(errs, validation.FieldError{Field: name, Code: "read_only", Message: "field cannot be updated"})
+			continue
+		}
+
+		field := patched.Field(fields[name])
+		field.Set(reflect.Zero(field.Type()))
+		if !present {
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, field.Addr().Interface()); err != nil {
+			errs = append(errs, validation.FieldError{Field: name, Code: "invalid_type", Message: "must be a " + jsonType(field.Type())})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	val.Set(patched)
+	return nil
+}
+
+// jsonType names the JSON type a Go type is encoded as
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+// patchMediaType returns the patch format of r, writing a 415 naming the
+// accepted formats for any other content type
+func patchMediaType(w http.ResponseWriter, r *http.Request, accepted ...string) (string, bool) {
+	mediaType := mergePatchType
+	if header := r.Header.Get("Content-Type"); header != "" {
+		parsed, _, err := mime.ParseMediaType(header)
+		if err == nil && parsed != "application/json" {
+			mediaType = parsed
+		} else if err != nil {
+			mediaType = ""
+		}
+	}
+
+	if !slices.Contains(accepted, mediaType) {
+		w.Header().Set("Accept-Patch", strings.Join(accepted, ", "))
+		problem.Error(w, r, http.StatusUnsupportedMediaType, "Content-Type must be one of "+strings.Join(accepted, ", "))
+		return "", false
+	}
+	return mediaType, true
+}
+
+// decodePatch reads the merge patch or JSON Patch body of a PATCH request,
+// writing the error response itself when that fails
+func decodePatch(w http.ResponseWriter, r *http.Request) (patch, bool) {
+	mediaType, ok := patchMediaType(w, r, mergePatchType, jsonPatchType)
+	if !ok {
+		return nil, false
+	}
+
+	if mediaType == jsonPatchType {
+		var p jsonPatch
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			log.Println(err)
+			problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+			return nil, false
+		}
+		if err := p.validate(); err != nil {
+			problem.Error(w, r, http.StatusBadRequest, err.Error())
+			return nil, false
+		}
+		return p, true
+	}
+
+	var p mergePatch
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil || p == nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return nil, false
+	}
+	return p, true
+}
+
+// writePatchError sends the response for an error of patchStruct, which may
+// be wrapped in a *repository.ItemError for bulk patches
+func writePatchError(w http.ResponseWriter, r *http.Request, err error) {
+	var errs patchErrors
+	if errors.As(err, &errs) {
+		var itemErr *repository.ItemError
+		if errors.As(err, &itemErr) {
+			prefixed := make(patchErrors, len(errs))
+			for i, fieldErr := range errs {
+				fieldErr.Field = fmt.Sprintf("[%d].%s", itemErr.Index, fieldErr.Field)
+				prefixed[i] = fieldErr
+			}
+			errs = prefixed
+		}
+		problem.Validation(w, r, errs)
+		return
+	}
+	if errors.Is(err, errPatchTestFailed) {
+		problem.Error(w, r, http.StatusConflict, err.Error())
+		return
+	}
+	log.Println(err)
+	problem.Error(w, r, http.StatusInternalServerError, "Unable to apply patch")
+}
@@ -0,0 +1,41 @@
+package handlers_test
+
+import (
+	"go-rest-api/internal/models"
+	"net/http"
+	"testing"
+)
+
+func TestTeachersPatch(t *testing.T) {
+	h := newTeachersHandler()
+	teacher := createTeachers(t, h, teacherJSON("Emma", "Stone", "emma@school.test"))[0]
+	id := pathValue("id", teacher.ID)
+	target := "/teachers/" + teacher.ID
+
+	tests := []struct {
+		name   string
+		body   string
+		opts   []option
+		status int
+	}{
+		{"merge patch", `{"last_name":"Watson"}`, nil, http.StatusOK},
+		{"stale If-Match", `{"last_name":"Grey"}`, []option{header("If-Match", `"1"`)}, http.StatusPreconditionFailed},
+		{"current If-Match", `{"last_name":"Grey"}`, []option{header("If-Match", `"2"`)}, http.StatusOK},
+		{"read-only field", `{"deleted_at":"2026-01-01 00:00:00"}`, nil, http.StatusUnprocessableEntity},
+		{"wrong type", `{"email":42}`, nil, http.StatusUnprocessableEntity},
+		{"failed test op", `[{"op":"test","path":"/last_name","value":"Stone"}]`, []option{header("Content-Type", "application/json-patch+json")}, http.StatusConflict},
+		{"json patch", `[{"op":"test","path":"/last_name","value":"Grey"},{"op":"replace","path":"/first_name","value":"Em"}]`, []option{header("Content-Type", "application/json-patch+json")}, http.StatusOK},
+		{"unsupported media type", `first_name=Em`, []option{header("Content-Type", "application/x-www-form-urlencoded")}, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.Patch, http.MethodPatch, target, tt.body, append([]option{id}, tt.opts...)...)
+			expectStatus(t, w, tt.status)
+		})
+	}
+
+	w := serve(h.Get, http.MethodGet, target, "", id)
+	if got := decode[models.Teacher](t, w, http.StatusOK); got.FirstName != "Em" || got.LastName != "Grey" {
+		t.Fatalf("got %+v", got)
+	}
+}
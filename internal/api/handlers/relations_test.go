@@ -0,0 +1,76 @@
+package handlers_test
+
+import (
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// lastNames lists the last names of the students in order
+func lastNames(students []models.Student) []string {
+	names := make([]string, len(students))
+	for i, student := range students {
+		names[i] = student.LastName
+	}
+	return names
+}
+
+func TestTeachersStudents(t *testing.T) {
+	classes := memory.NewClassRepository()
+	studentRepo := memory.NewStudentRepository(classes, nil)
+	h := handlers.NewTeachersHandler(memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), nil), studentRepo)
+	teacher := createTeachers(t, h, teacherJSON("Emma", "Stone", "emma@school.test"))[0]
+	createStudents(t, handlers.NewStudentsHandler(studentRepo),
+		studentJSON("Ava", "Lee", "ava@school.test", teacher.Class),
+		studentJSON("Ben", "Cole", "ben@school.test", teacher.Class),
+		studentJSON("Cara", "Diaz", "cara@school.test", teacher.Class),
+		studentJSON("Dan", "Fox", "dan@school.test", models.Classes[1]))
+	id := pathValue("id", teacher.ID)
+	target := "/teachers/" + teacher.ID + "/students"
+
+	tests := []struct {
+		name  string
+		query string
+		count int
+		want  []string
+	}{
+		{"first page", "?sort_by=last_name:asc&limit=2", 3, []string{"Cole", "Diaz"}},
+		{"second page", "?sort_by=last_name:asc&limit=2&page=2", 3, []string{"Lee"}},
+		{"first cursor page", "?sort_by=last_name:asc&limit=2&cursor=", 2, []string{"Cole", "Diaz"}},
+		{"filtered", "?first_name=Ava", 1, []string{"Lee"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h.Students, http.MethodGet, target+tt.query, "", id)
+			got := decode[listResponse[models.Student]](t, w, http.StatusOK)
+			if got.Count != tt.count || !slices.Equal(lastNames(got.Data), tt.want) {
+				t.Fatalf("got %d %v, want %d %v", got.Count, lastNames(got.Data), tt.count, tt.want)
+			}
+		})
+	}
+
+	t.Run("next cursor page", func(t *testing.T) {
+		w := serve(h.Students, http.MethodGet, target+"?sort_by=last_name:asc&limit=2&cursor=", "", id)
+		first := decode[listResponse[models.Student]](t, w, http.StatusOK)
+		w = serve(h.Students, http.MethodGet, target+"?sort_by=last_name:asc&limit=2&cursor="+first.NextCursor, "", id)
+		if got := decode[listResponse[models.Student]](t, w, http.StatusOK); !slices.Equal(lastNames(got.Data), []string{"Lee"}) {
+			t.Fatalf("got %v, want [Lee]", lastNames(got.Data))
+		}
+	})
+
+	t.Run("studentcount", func(t *testing.T) {
+		w := serve(h.StudentCount, http.MethodGet, "/teachers/"+teacher.ID+"/studentcount", "", id)
+		if got := decode[struct{ Count int }](t, w, http.StatusOK); got.Count != 3 {
+			t.Fatalf("count = %d, want 3", got.Count)
+		}
+	})
+
+	t.Run("unknown teacher", func(t *testing.T) {
+		missing := pathValue("id", "missing")
+		expectStatus(t, serve(h.Students, http.MethodGet, "/teachers/missing/students", "", missing), http.StatusNotFound)
+		expectStatus(t, serve(h.StudentCount, http.MethodGet, "/teachers/missing/studentcount", "", missing), http.StatusNotFound)
+	})
+}
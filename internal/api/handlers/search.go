@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSearchLength bounds the q parameter of GET /search
+const maxSearchLength = 200
+
+type SearchHandler struct {
+	repo repository.SearchRepository
+}
+
+func NewSearchHandler(repo repository.SearchRepository) *SearchHandler {
+	return &SearchHandler{repo: repo}
+}
+
+// Search handles GET /search?q=, returning teachers, students and execs
+// whose names or emails match q, best matches first. It supports page and
+// limit but not cursors, sorting or filters.
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		problem.Error(w, r, http.StatusBadRequest, "q is required")
+		return
+	}
+	if utf8.RuneCountInString(q) > maxSearchLength {
+		problem.Error(w, r, http.StatusBadRequest, fmt.Sprintf("q must be at most %d characters", maxSearchLength))
+		return
+	}
+
+	opts, err := parseListOptions(r, nil)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if opts.Keyset != nil {
+		problem.Error(w, r, http.StatusBadRequest, "cursor pagination is not supported by search")
+		return
+	}
+
+	resultPage, err := fetchPage(r.Context(), opts, nil, func(ctx context.Context, opts repository.ListOptions) ([]models.SearchResult, int, error) {
+		return h.repo.Search(ctx, q, opts.Limit, opts.Offset)
+	})
+	if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	writePage(w, r, opts, resultPage)
+}
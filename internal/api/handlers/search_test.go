@@ -0,0 +1,80 @@
+package handlers_test
+
+import (
+	"context"
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/auth"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchRanksAndPages(t *testing.T) {
+	ctx := context.Background()
+	classes := memory.NewClassRepository()
+	teachers := memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), nil)
+	students := memory.NewStudentRepository(classes, nil)
+	execs := memory.NewExecRepository(nil)
+	h := handlers.NewSearchHandler(memory.NewSearchRepository(teachers, students, execs))
+
+	if _, err := teachers.Create(ctx, []models.Teacher{
+		{FirstName: "Emma", LastName: "Stone", Email: "t1@school.test", Class: models.Classes[0], Subject: models.Subjects[0]},
+		{FirstName: "Liam", LastName: "Reed", Email: "t2@school.test", Class: models.Classes[0], Subject: models.Subjects[0]},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := students.Create(ctx, []models.Student{
+		{FirstName: "Emma", LastName: "Smith", Email: "s1@school.test", Class: models.Classes[0]},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := execs.Create(ctx, []models.Exec{
+		{FirstName: "Emma", LastName: "Stone", Email: "e1@school.test", Username: "estone", Password: "x", Role: auth.RoleCounselor},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Results are ordered by score, then type
+	tests := []struct {
+		query     string
+		want      []string
+		wantCount int
+		wantNext  bool
+	}{
+		{"q=emma+stone", []string{"exec Stone", "teacher Stone", "student Smith"}, 3, false},
+		{"q=EMMA", []string{"exec Stone", "student Smith", "teacher Stone"}, 3, false},
+		{"q=emma+stone&limit=1", []string{"exec Stone"}, 3, true},
+		{"q=emma+stone&limit=1&page=2", []string{"teacher Stone"}, 3, true},
+		{"q=emma+stone&limit=2&page=2", []string{"student Smith"}, 3, false},
+		{"q=nobody", []string{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			w := serve(h.Search, http.MethodGet, "/search?"+tt.query, "")
+			got := decode[listResponse[models.SearchResult]](t, w, http.StatusOK)
+
+			results := []string{}
+			for _, result := range got.Data {
+				results = append(results, result.Type+" "+result.LastName)
+			}
+			if !slices.Equal(results, tt.want) {
+				t.Errorf("got %v, want %v", results, tt.want)
+			}
+			if got.Count != tt.wantCount {
+				t.Errorf("count = %d, want %d", got.Count, tt.wantCount)
+			}
+			if link := w.Header().Get("Link"); strings.Contains(link, `rel="next"`) != tt.wantNext {
+				t.Errorf("Link = %q, want a next link: %v", link, tt.wantNext)
+			}
+		})
+	}
+
+	for _, query := range []string{"", "q=+", "q=emma&cursor="} {
+		w := serve(h.Search, http.MethodGet, "/search?"+query, "")
+		expectStatus(t, w, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,63 @@
+package handlers_test
+
+import (
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"testing"
+)
+
+func TestDeleteAndRestore(t *testing.T) {
+	classes := memory.NewClassRepository()
+	studentRepo := memory.NewStudentRepository(classes, nil)
+	teachers := handlers.NewTeachersHandler(memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), nil), studentRepo)
+	students := handlers.NewStudentsHandler(studentRepo)
+
+	tests := []struct {
+		name                       string
+		create                     func(t *testing.T) string
+		get, delete, restore, list http.HandlerFunc
+	}{
+		{
+			name: "teachers",
+			create: func(t *testing.T) string {
+				return createTeachers(t, teachers, teacherJSON("Emma", "Stone", "emma@school.test"))[0].ID
+			},
+			get: teachers.Get, delete: teachers.Delete, restore: teachers.Restore, list: teachers.List,
+		},
+		{
+			name: "students",
+			create: func(t *testing.T) string {
+				w := serve(students.Create, http.MethodPost, "/students/", `[{"first_name":"Ava","last_name":"Lee","email":"ava@school.test","class":"`+models.Classes[0]+`"}]`)
+				return decode[listResponse[models.Student]](t, w, http.StatusCreated).Data[0].ID
+			},
+			get: students.Get, delete: students.Delete, restore: students.Restore, list: students.List,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := tt.create(t)
+			target := "/" + tt.name + "/" + id
+			idParam := pathValue("id", id)
+
+			expectStatus(t, serve(tt.delete, http.MethodDelete, target, "", idParam), http.StatusOK)
+			expectStatus(t, serve(tt.get, http.MethodGet, target, "", idParam), http.StatusNotFound)
+			expectStatus(t, serve(tt.delete, http.MethodDelete, target, "", idParam), http.StatusNotFound)
+
+			w := serve(tt.list, http.MethodGet, "/"+tt.name+"/", "")
+			if got := decode[listResponse[map[string]any]](t, w, http.StatusOK); got.Count != 0 {
+				t.Fatalf("listed %d, want the deleted row hidden", got.Count)
+			}
+			w = serve(tt.list, http.MethodGet, "/"+tt.name+"/?include_deleted=true", "")
+			if got := decode[listResponse[map[string]any]](t, w, http.StatusOK); got.Count != 1 || got.Data[0]["deleted_at"] == nil {
+				t.Fatalf("got %+v, want the deleted row", got)
+			}
+
+			expectStatus(t, serve(tt.restore, http.MethodPost, target+"/restore", "", idParam), http.StatusOK)
+			expectStatus(t, serve(tt.get, http.MethodGet, target, "", idParam), http.StatusOK)
+			expectStatus(t, serve(tt.restore, http.MethodPost, target+"/restore", "", idParam), http.StatusNotFound)
+		})
+	}
+}
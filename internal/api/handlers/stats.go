@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+// DBStatsHandler reports the state of the shared connection pool
+func DBStatsHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := db.Stats()
+		response := struct {
+			Status string `json:"status"`
+			Data   struct {
+				MaxOpenConnections int    `json:"max_open_connections"`
+				OpenConnections    int    `json:"open_connections"`
+				InUse              int    `json:"in_use"`
+				Idle               int    `json:"idle"`
+				WaitCount          int64  `json:"wait_count"`
+				WaitDuration       string `json:"wait_duration"`
+				MaxIdleClosed      int64  `json:"max_idle_closed"`
+				MaxIdleTimeClosed  int64  `json:"max_idle_time_closed"`
+				MaxLifetimeClosed  int64  `json:"max_lifetime_closed"`
+			} `json:"data"`
+		}{Status: "success"}
+		response.Data.MaxOpenConnections = stats.MaxOpenConnections
+		response.Data.OpenConnections = stats.OpenConnections
+		response.Data.InUse = stats.InUse
+		response.Data.Idle = stats.Idle
+		response.Data.WaitCount = stats.WaitCount
+		response.Data.WaitDuration = stats.WaitDuration.String()
+		response.Data.MaxIdleClosed = stats.MaxIdleClosed
+		response.Data.MaxIdleTimeClosed = stats.MaxIdleTimeClosed
+		response.Data.MaxLifetimeClosed = stats.MaxLifetimeClosed
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -1,26 +1,457 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/validation"
+	"log"
 	"net/http"
 )
 
-func StudentsHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		w.Write([]byte("Hello GET students Route"))
-		fmt.Println("Hello GET students Route")
-	case http.MethodPost:
-		w.Write([]byte("Hello POST students Route"))
-		fmt.Println("Hello POST students Route")
-	case http.MethodPut:
-		w.Write([]byte("Hello PUT students Route"))
-		fmt.Println("Hello PUT students Route")
-	case http.MethodPatch:
-		w.Write([]byte("Hello PATCH students Route"))
-		fmt.Println("Hello PATCH students Route")
-	case http.MethodDelete:
-		w.Write([]byte("Hello DELETE students Route"))
-		fmt.Println("Hello DELETE students Route")
+// studentSelectable are the fields a sparse fieldset may name
+var studentSelectable = map[string]bool{
+	"id":         true,
+	"first_name": true,
+	"last_name":  true,
+	"email":      true,
+	"class":      true,
+	"deleted_at": true,
+}
+
+// studentFields are the columns that can be used for filtering and sorting
+var studentFields = map[string]fieldKind{
+	"first_name": textField,
+	"last_name":  textField,
+	"email":      textField,
+	"class":      textField,
+}
+
+// studentImportable are the columns a CSV import may have
+var studentImportable = map[string]bool{
+	"first_name": true,
+	"last_name":  true,
+	"email":      true,
+	"class":      true,
+}
+
+type StudentsHandler struct {
+	repo repository.StudentRepository
+}
+
+func NewStudentsHandler(repo repository.StudentRepository) *StudentsHandler {
+	return &StudentsHandler{repo: repo}
+}
+
+// List handles GET /students/ with pagination, filters and sorting
+func (h *StudentsHandler) List(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r, studentFields)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	fields, err := parseFields(r, studentSelectable)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	studentPage, err := fetchPage(r.Context(), opts, fields, h.repo.List)
+	if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	writePage(w, r, opts, studentPage)
+}
+
+// Get handles GET /students/{id}
+func (h *StudentsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	fields, err := parseFields(r, studentSelectable)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	student, err := h.repo.Get(r.Context(), idStr, fields...)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Student not found")
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project(student, fields))
+}
+
+// Create handles POST /students/
+func (h *StudentsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var newStudents []models.Student
+	err := json.NewDecoder(r.Body).Decode(&newStudents)
+	if err != nil {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Error parsing JSON")
+		return
+	}
+
+	switch r.URL.Query().Get("mode") {
+	case "":
+	case "partial":
+		h.addStudentsPartial(w, r, newStudents)
+		return
+	default:
+		problem.Error(w, r, http.StatusBadRequest, "mode must be \"partial\" or omitted")
+		return
+	}
+
+	if errs := validation.Slice(newStudents); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	addedStudents, err := h.repo.Create(r.Context(), newStudents)
+	if errs, ok := referenceErrors(err); ok {
+		problem.Validation(w, r, errs)
+		return
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email already exists"+itemSuffix(err))
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Error inserting student")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := struct {
+		Status string           `json:"status"`
+		Count  int              `json:"count"`
+		Data   []models.Student `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(addedStudents),
+		Data:   addedStudents,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// addStudentsPartial stores every valid student it can and reports the
+// outcome of each item with a 207 Multi-Status
+func (h *StudentsHandler) addStudentsPartial(w http.ResponseWriter, r *http.Request, newStudents []models.Student) {
+	results := make([]bulkItemResult, len(newStudents))
+	valid := make([]models.Student, 0, len(newStudents))
+	validIndexes := make([]int, 0, len(newStudents))
+	for i := range newStudents {
+		results[i].Index = i
+		if errs := validation.Struct(&newStudents[i]); len(errs) > 0 {
+			results[i].Status = bulkInvalid
+			results[i].Errors = errs
+			continue
+		}
+		valid = append(valid, newStudents[i])
+		validIndexes = append(validIndexes, i)
+	}
+
+	created, errs := h.repo.CreateEach(r.Context(), valid)
+	count := 0
+	for j, i := range validIndexes {
+		fieldErrs, isReference := referenceErrors(errs[j])
+		switch {
+		case errs[j] == nil:
+			results[i].Status = bulkCreated
+			results[i].Data = created[j]
+			count++
+		case isReference:
+			results[i].Status = bulkInvalid
+			results[i].Errors = fieldErrs
+		case errors.Is(errs[j], repository.ErrConflict):
+			results[i].Status = bulkConflict
+			results[i].Error = "Email already exists"
+		default:
+			log.Println(errs[j])
+			results[i].Status = bulkFailed
+			results[i].Error = "Error inserting student"
+		}
+	}
+
+	writeMultiStatus(w, count, results)
+}
+
+// Import handles POST /students/import, storing the students of a text/csv
+// body in one transaction like Create. ?dry_run=true only reports what the
+// import would do.
+func (h *StudentsHandler) Import(w http.ResponseWriter, r *http.Request) {
+	importCSV(w, r, studentImportable, "student", h.repo.CheckCreate, h.repo.Create)
+}
+
+// Update handles PUT /students/{id}
+func (h *StudentsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	var updatedStudent models.Student
+	err := json.NewDecoder(r.Body).Decode(&updatedStudent)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	if errs := validation.Struct(&updatedStudent); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	existingStudent, err := h.repo.Get(r.Context(), idStr)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			problem.Error(w, r, http.StatusNotFound, "Student not found")
+			return
+		}
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+		return
+	}
+
+	updatedStudent.ID = existingStudent.ID
+	err = h.repo.Update(r.Context(), updatedStudent)
+	if errs, ok := referenceErrors(err); ok {
+		problem.Validation(w, r, errs)
+		return
 	}
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email already exists")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedStudent)
+}
+
+// Patch handles PATCH /students/{id} with a merge patch or JSON Patch body
+func (h *StudentsHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	updates, ok := decodePatch(w, r)
+	if !ok {
+		return
+	}
+
+	existingStudent, err := h.repo.Get(r.Context(), idStr)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			problem.Error(w, r, http.StatusNotFound, "Student not found")
+			return
+		}
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+		return
+	}
+
+	if err := patchStruct(&existingStudent, updates, nil); err != nil {
+		writePatchError(w, r, err)
+		return
+	}
+
+	if errs := validation.Struct(&existingStudent); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.Update(r.Context(), existingStudent)
+	if errs, ok := referenceErrors(err); ok {
+		problem.Validation(w, r, errs)
+		return
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email already exists")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(existingStudent)
+}
+
+// PatchMany handles PATCH /students/, applying an array of merge patches with
+// an "id" member each in one transaction. Nothing is changed if
+// any id is missing.
+func (h *StudentsHandler) PatchMany(w http.ResponseWriter, r *http.Request) {
+	if _, ok := patchMediaType(w, r, mergePatchType); !ok {
+		return
+	}
+
+	var updates []mergePatch
+	err := json.NewDecoder(r.Body).Decode(&updates)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	students := make([]models.Student, 0, len(updates))
+	for i, update := range updates {
+		id, _ := update["id"].(string)
+		if id == "" {
+			problem.Error(w, r, http.StatusBadRequest, fmt.Sprintf("id is required (item %d)", i))
+			return
+		}
+
+		existingStudent, err := h.repo.Get(r.Context(), id)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				problem.Error(w, r, http.StatusNotFound, fmt.Sprintf("Student not found (item %d)", i))
+				return
+			}
+			log.Println(err)
+			problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+			return
+		}
+
+		if err := patchStruct(&existingStudent, update, nil); err != nil {
+			writePatchError(w, r, &repository.ItemError{Index: i, Err: err})
+			return
+		}
+		students = append(students, existingStudent)
+	}
+
+	if errs := validation.Slice(students); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.UpdateMany(r.Context(), students)
+	if errs, ok := referenceErrors(err); ok {
+		problem.Validation(w, r, errs)
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Student not found"+itemSuffix(err))
+		return
+	} else if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email already exists"+itemSuffix(err))
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string           `json:"status"`
+		Count  int              `json:"count"`
+		Data   []models.Student `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(students),
+		Data:   students,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteMany handles DELETE /students/, soft deleting a JSON array of ids in one
+// transaction. Nothing is deleted if any id is missing.
+func (h *StudentsHandler) DeleteMany(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	err := json.NewDecoder(r.Body).Decode(&ids)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+	if len(ids) == 0 {
+		problem.Error(w, r, http.StatusBadRequest, "At least one id is required")
+		return
+	}
+
+	err = h.repo.DeleteMany(r.Context(), ids)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Student not found"+itemSuffix(err))
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to delete data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string   `json:"status"`
+		Count  int      `json:"count"`
+		IDs    []string `json:"ids"`
+	}{
+		Status: "success",
+		Count:  len(ids),
+		IDs:    ids,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Delete handles DELETE /students/{id}, soft deleting the record
+func (h *StudentsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	err := h.repo.Delete(r.Context(), idStr)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Student not found")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to delete data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string `json:"status"`
+		ID     string `json:"id"`
+	}{
+		Status: "success",
+		ID:     idStr,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Restore handles POST /students/{id}/restore, undoing a soft delete
+func (h *StudentsHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	err := h.repo.Restore(r.Context(), idStr)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Deleted student not found")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to restore data")
+		return
+	}
+
+	student, err := h.repo.Get(r.Context(), idStr)
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(student)
 }
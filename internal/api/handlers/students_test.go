@@ -0,0 +1,52 @@
+package handlers_test
+
+import (
+	"fmt"
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// studentJSON is the body of a student with the given names
+func studentJSON(first, last, email, class string) string {
+	return fmt.Sprintf(`{"first_name":%q,"last_name":%q,"email":%q,"class":%q}`, first, last, email, class)
+}
+
+// createStudents stores the students and returns them with their IDs
+func createStudents(t *testing.T, h *handlers.StudentsHandler, students ...string) []models.Student {
+	t.Helper()
+	w := serve(h.Create, http.MethodPost, "/students/", "["+strings.Join(students, ",")+"]")
+	return decode[listResponse[models.Student]](t, w, http.StatusCreated).Data
+}
+
+func TestStudentsCreatePartial(t *testing.T) {
+	h := handlers.NewStudentsHandler(memory.NewStudentRepository(memory.NewClassRepository(), nil))
+	class := models.Classes[0]
+	createStudents(t, h, studentJSON("Ava", "Lee", "ava@school.test", class))
+
+	w := serve(h.Create, http.MethodPost, "/students/?mode=partial", "["+
+		studentJSON("Noah", "Reed", "noah@school.test", class)+","+
+		studentJSON("Ava", "Other", "AVA@school.test", class)+","+
+		studentJSON("Mia", "Lane", "mia@school.test", "9Z")+"]")
+	got := decode[multiStatus[models.Student]](t, w, http.StatusMultiStatus)
+	if got.Count != 1 || len(got.Results) != 3 {
+		t.Fatalf("got %s, want one of three created", w.Body)
+	}
+	if r := got.Results[0]; r.Index != 0 || r.Status != "created" || r.Data.ID == "" || r.Data.Email != "noah@school.test" {
+		t.Fatalf("results[0] = %+v", r)
+	}
+	if r := got.Results[1]; r.Index != 1 || r.Status != "conflict" || r.Error != "Email already exists" {
+		t.Fatalf("results[1] = %+v", r)
+	}
+	if r := got.Results[2]; r.Index != 2 || r.Status != "invalid" || len(r.Errors) != 1 || r.Errors[0].Field != "class" {
+		t.Fatalf("results[2] = %+v", r)
+	}
+
+	w = serve(h.List, http.MethodGet, "/students/", "")
+	if got := decode[listResponse[models.Student]](t, w, http.StatusOK); got.Count != 2 {
+		t.Fatalf("count = %d after a partial batch, want 2", got.Count)
+	}
+}
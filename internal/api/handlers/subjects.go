@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/validation"
+	"log"
+	"net/http"
+)
+
+type SubjectsHandler struct {
+	repo repository.SubjectRepository
+}
+
+func NewSubjectsHandler(repo repository.SubjectRepository) *SubjectsHandler {
+	return &SubjectsHandler{repo: repo}
+}
+
+// List handles GET /subjects/
+func (h *SubjectsHandler) List(w http.ResponseWriter, r *http.Request) {
+	subjectList, err := h.repo.List(r.Context())
+	if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	response := struct {
+		Status string           `json:"status"`
+		Count  int              `json:"count"`
+		Data   []models.Subject `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(subjectList),
+		Data:   subjectList,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// Get handles GET /subjects/{subject}
+func (h *SubjectsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	subject, err := h.repo.Get(r.Context(), r.PathValue("subject"))
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Subject not found")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subject)
+}
+
+// Create handles POST /subjects/ with an array of subjects, stored all or
+// nothing
+func (h *SubjectsHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var newSubjects []models.Subject
+	err := json.NewDecoder(r.Body).Decode(&newSubjects)
+	if err != nil {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Error parsing JSON")
+		return
+	}
+
+	if errs := validation.Slice(newSubjects); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.Create(r.Context(), newSubjects)
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Subject already exists"+itemSuffix(err))
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Error inserting subject")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := struct {
+		Status string           `json:"status"`
+		Count  int              `json:"count"`
+		Data   []models.Subject `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(newSubjects),
+		Data:   newSubjects,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Update handles PUT /subjects/{subject}, renaming the subject. Teachers of
+// the subject move with it.
+func (h *SubjectsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("subject")
+
+	var updatedSubject models.Subject
+	err := json.NewDecoder(r.Body).Decode(&updatedSubject)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+
+	if errs := validation.Struct(&updatedSubject); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.Rename(r.Context(), name, updatedSubject.Name)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Subject not found")
+		return
+	} else if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Subject already exists")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updatedSubject)
+}
+
+// Delete handles DELETE /subjects/{subject}. A subject that is still taught
+// can't be deleted.
+func (h *SubjectsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("subject")
+
+	err := h.repo.Delete(r.Context(), name)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Subject not found")
+		return
+	} else if errors.Is(err, repository.ErrInUse) {
+		problem.Error(w, r, http.StatusConflict, "Subject is still taught by teachers")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to delete data")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string `json:"status"`
+		Name   string `json:"name"`
+	}{
+		Status: "success",
+		Name:   name,
+	}
+	json.NewEncoder(w).Encode(response)
+}
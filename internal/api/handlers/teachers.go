@@ -1,488 +1,579 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"github.com/google/uuid"
+	"go-rest-api/internal/api/problem"
 	"go-rest-api/internal/models"
-	"go-rest-api/internal/repository/sqlconnect"
+	"go-rest-api/internal/repository"
+	"go-rest-api/internal/validation"
 	"log"
 	"net/http"
-	"reflect"
-	"strconv"
-	"strings"
 )
 
-var (
-	teachers = make(map[int]models.Teacher)
-	nextID   = 1
-)
+// teacherSelectable are the fields a sparse fieldset may name
+var teacherSelectable = map[string]bool{
+	"id":         true,
+	"first_name": true,
+	"last_name":  true,
+	"email":      true,
+	"class":      true,
+	"subject":    true,
+	"deleted_at": true,
+}
 
-func isValidSortOrder(order string) bool {
-	return order == "asc" || order == "desc"
+// teacherFields are the columns that can be used for filtering and sorting
+var teacherFields = map[string]fieldKind{
+	"first_name": textField,
+	"last_name":  textField,
+	"email":      textField,
+	"class":      textField,
+	"subject":    textField,
 }
 
-func isValidSortField(field string) bool {
-	validFields := map[string]bool{
-		"first_name": true,
-		"last_name":  true,
-		"email":      true,
-		"class":      true,
-		"subject":    true,
-	}
+// teacherImportable are the columns a CSV import may have
+var teacherImportable = map[string]bool{
+	"first_name": true,
+	"last_name":  true,
+	"email":      true,
+	"class":      true,
+	"subject":    true,
+}
 
-	return validFields[field]
+type TeachersHandler struct {
+	repo     repository.TeacherRepository
+	students repository.StudentRepository
 }
 
-func init() {
-	teachers[nextID] = models.Teacher{
-		ID:        "1",
-		FirstName: "Juan",
-		LastName:  "Perez",
-		Class:     "Apo",
-		Subject:   "Math",
-	}
-	nextID++
-	teachers[nextID] = models.Teacher{
-		ID:        "2",
-		FirstName: "Pedro",
-		LastName:  "Castro",
-		Class:     "Rizal",
-		Subject:   "Science",
-	}
-	nextID++
+func NewTeachersHandler(repo repository.TeacherRepository, students repository.StudentRepository) *TeachersHandler {
+	return &TeachersHandler{repo: repo, students: students}
 }
 
-func TeachersHandler(w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		getTeachersHandler(w, r)
-	case http.MethodPost:
-		addTeachersHandler(w, r)
-	case http.MethodPut:
-		updateTeacherHandler(w, r)
-	case http.MethodPatch:
-		PatchTeacherHandler(w, r)
-	case http.MethodDelete:
-		DeleteTeacherHandler(w, r)
+// List handles GET /teachers/ with pagination, filters and sorting
+func (h *TeachersHandler) List(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseListOptions(r, teacherFields)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
-}
 
-func getTeachersHandler(w http.ResponseWriter, r *http.Request) {
-	db, err := sqlconnect.ConnectDb()
+	fields, err := parseFields(r, teacherSelectable)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	teacherPage, err := fetchPage(r.Context(), opts, fields, h.repo.List)
 	if err != nil {
 		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
 		return
 	}
-	defer db.Close()
 
-	path := strings.TrimPrefix(r.URL.Path, "/teachers/")
-	idStr := strings.TrimSuffix(path, "/")
+	writePage(w, r, opts, teacherPage)
+}
 
-	if idStr == "" {
-		pageStr := r.URL.Query().Get("page")
-		limitStr := r.URL.Query().Get("limit")
+// Get handles GET /teachers/{id}, sending the version, and the fieldset of a
+// sparse response, as the ETag and honouring If-None-Match
+func (h *TeachersHandler) Get(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
 
-		if pageStr == "" {
-			pageStr = "1"
-		}
-		if limitStr == "" {
-			limitStr = "10"
-		}
+	fields, err := parseFields(r, teacherSelectable)
+	if err != nil {
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
 
-		page, _ := strconv.Atoi(pageStr)
-		limit, _ := strconv.Atoi(limitStr)
+	// The version is read even for a sparse fieldset, for the ETag
+	teacher, err := h.repo.Get(r.Context(), idStr, withKeyFields(fields, []string{"version"})...)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Teacher not found")
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return
+	}
 
-		maxLimit := 100
-		if limit > maxLimit {
-			http.Error(w, "Limit cannot be greater than 100", http.StatusBadRequest)
-			return
-		}
+	etag := versionETag(teacher.Version, fields)
+	w.Header().Set("ETag", etag)
+	if notModified(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
 
-		query := "SELECT id, first_name, last_name, email, class, subject FROM teachers WHERE 1=1"
-		queryCount := "SELECT COUNT(id) FROM teachers WHERE 1=1"
-		var args []interface{}
-		var argsCount []interface{}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(project(teacher, fields))
+}
 
-		query, args, queryCount, argsCount = addFilters(r, query, queryCount, args, argsCount)
+// Create handles POST /teachers/
+func (h *TeachersHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var newTeachers []models.Teacher
+	err := json.NewDecoder(r.Body).Decode(&newTeachers)
+	if err != nil {
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Error parsing JSON")
+		return
+	}
 
-		query = addSorting(r, query)
+	switch r.URL.Query().Get("mode") {
+	case "":
+	case "partial":
+		h.addTeachersPartial(w, r, newTeachers)
+		return
+	default:
+		problem.Error(w, r, http.StatusBadRequest, "mode must be \"partial\" or omitted")
+		return
+	}
 
-		// Add pagination
-		offset := (page - 1) * limit
-		query += " LIMIT ? OFFSET ?"
-		args = append(args, limit, offset)
+	if errs := validation.Slice(newTeachers); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
 
-		rows, err := db.Query(query, args...)
-		if err != nil {
-			fmt.Println(err)
-			http.Error(w, "Database query error", http.StatusInternalServerError)
-			return
-		}
-		defer rows.Close()
-
-		teacherList := make([]models.Teacher, 0)
-		for rows.Next() {
-			var teacher models.Teacher
-			err := rows.Scan(&teacher.ID, &teacher.FirstName, &teacher.LastName, &teacher.Email, &teacher.Class, &teacher.Subject)
-			if err != nil {
-				http.Error(w, "Database scan error", http.StatusInternalServerError)
-				return
-			}
-			teacherList = append(teacherList, teacher)
-		}
+	// All or nothing: the repository inserts the batch in one transaction
+	addedTeachers, err := h.repo.Create(r.Context(), newTeachers)
+	if errs, ok := referenceErrors(err); ok {
+		problem.Validation(w, r, errs)
+		return
+	}
+	if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email already exists"+itemSuffix(err))
+		return
+	} else if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Error inserting teacher")
+		return
+	}
 
-		var totalTeachers int
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	response := struct {
+		Status string           `json:"status"`
+		Count  int              `json:"count"`
+		Data   []models.Teacher `json:"data"`
+	}{
+		Status: "success",
+		Count:  len(addedTeachers),
+		Data:   addedTeachers,
+	}
 
-		err = db.QueryRow(queryCount, argsCount...).Scan(&totalTeachers)
-		if err != nil {
-			fmt.Println(err)
-			totalTeachers = 0
+	json.NewEncoder(w).Encode(response)
+}
+
+// addTeachersPartial stores every valid teacher it can and reports the
+// outcome of each item with a 207 Multi-Status
+func (h *TeachersHandler) addTeachersPartial(w http.ResponseWriter, r *http.Request, newTeachers []models.Teacher) {
+	results := make([]bulkItemResult, len(newTeachers))
+	valid := make([]models.Teacher, 0, len(newTeachers))
+	validIndexes := make([]int, 0, len(newTeachers))
+	for i := range newTeachers {
+		results[i].Index = i
+		if errs := validation.Struct(&newTeachers[i]); len(errs) > 0 {
+			results[i].Status = bulkInvalid
+			results[i].Errors = errs
+			continue
 		}
+		valid = append(valid, newTeachers[i])
+		validIndexes = append(validIndexes, i)
+	}
 
-		response := struct {
-			Status string           `json:"status"`
-			Count  int              `json:"count"`
-			Data   []models.Teacher `json:"data"`
-		}{
-			Status: "success",
-			Count:  totalTeachers,
-			Data:   teacherList,
+	created, errs := h.repo.CreateEach(r.Context(), valid)
+	count := 0
+	for j, i := range validIndexes {
+		fieldErrs, isReference := referenceErrors(errs[j])
+		switch {
+		case errs[j] == nil:
+			results[i].Status = bulkCreated
+			results[i].Data = created[j]
+			count++
+		case isReference:
+			results[i].Status = bulkInvalid
+			results[i].Errors = fieldErrs
+		case errors.Is(errs[j], repository.ErrConflict):
+			results[i].Status = bulkConflict
+			results[i].Error = "Email already exists"
+		default:
+			log.Println(errs[j])
+			results[i].Status = bulkFailed
+			results[i].Error = "Error inserting teacher"
 		}
+	}
+
+	writeMultiStatus(w, count, results)
+}
+
+// Import handles POST /teachers/import, storing the teachers of a text/csv
+// body in one transaction like Create. ?dry_run=true only reports what the
+// import would do.
+func (h *TeachersHandler) Import(w http.ResponseWriter, r *http.Request) {
+	importCSV(w, r, teacherImportable, "teacher", h.repo.CheckCreate, h.repo.Create)
+}
+
+// Update handles PUT /teachers/{id}. If-Match, when sent, must name the
+// current ETag.
+func (h *TeachersHandler) Update(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+	var updatedTeacher models.Teacher
+	err := json.NewDecoder(r.Body).Decode(&updatedTeacher)
+	if err != nil {
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
 	}
 
-	var teacher models.Teacher
+	if errs := validation.Struct(&updatedTeacher); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
 
-	query := "SELECT id, first_name, last_name, email, class, subject FROM teachers WHERE id = ?"
-	row := db.QueryRow(query, idStr)
+	existingTeacher, err := h.repo.Get(r.Context(), idStr)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			problem.Error(w, r, http.StatusNotFound, "Teacher not found")
+			return
+		}
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+		return
+	}
+
+	if !checkIfMatch(w, r, existingTeacher.Version) {
+		return
+	}
 
-	err = row.Scan(&teacher.ID, &teacher.FirstName, &teacher.LastName, &teacher.Email, &teacher.Class, &teacher.Subject)
-	if err == sql.ErrNoRows {
-		http.Error(w, "Teacher not found", http.StatusNotFound)
+	updatedTeacher.ID = existingTeacher.ID
+	updatedTeacher.Version = existingTeacher.Version
+	err = h.repo.Update(r.Context(), updatedTeacher)
+	if errs, ok := referenceErrors(err); ok {
+		problem.Validation(w, r, errs)
+		return
+	}
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		writeVersionMismatch(w, r, "Teacher was modified by another request")
+		return
+	} else if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email already exists")
 		return
 	} else if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Database query error", http.StatusInternalServerError)
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
 		return
 	}
 
+	w.Header().Set("ETag", versionETag(updatedTeacher.Version+1, nil))
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(teacher)
+	json.NewEncoder(w).Encode(updatedTeacher)
 }
 
-func addSorting(r *http.Request, query string) string {
-	// teachers/?sort_by=name:asc&sort_by=class:desc
-	sortParams := r.URL.Query()["sort_by"] // slice of strings
-	if len(sortParams) > 0 {
-		query += " ORDER BY"
-		for i, param := range sortParams {
-			parts := strings.Split(param, ":")
-			if len(parts) != 2 {
-				continue
-			}
-			field, order := parts[0], parts[1]
-			if !isValidSortField(field) || !isValidSortOrder(order) {
-				continue
-			}
-			if i > 0 {
-				query += ","
-			}
-			query += " " + field + " " + order
+// Patch handles PATCH /teachers/{id} with a merge patch or JSON Patch body.
+// If-Match, when sent, must name the current ETag.
+func (h *TeachersHandler) Patch(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	updates, ok := decodePatch(w, r)
+	if !ok {
+		return
+	}
+
+	existingTeacher, err := h.repo.Get(r.Context(), idStr)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			problem.Error(w, r, http.StatusNotFound, "Teacher not found")
+			return
 		}
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
+		return
 	}
 
-	return query
-}
+	if !checkIfMatch(w, r, existingTeacher.Version) {
+		return
+	}
 
-func addFilters(r *http.Request, query, queryCount string, args, argsCount []interface{}) (string, []interface{}, string, []interface{}) {
-	params := map[string]string{
-		"first_name": "first_name",
-		"last_name":  "last_name",
-		"email":      "email",
-		"class":      "class",
-		"subject":    "subject",
-	}
-
-	for param, dbField := range params {
-		value := r.URL.Query().Get(param)
-		if value != "" {
-			query += " AND " + dbField + " = ?"
-			queryCount += " AND " + dbField + " = ?"
-			args = append(args, value)
-			argsCount = append(argsCount, value)
-		}
+	if err := patchStruct(&existingTeacher, updates, nil); err != nil {
+		writePatchError(w, r, err)
+		return
+	}
+
+	if errs := validation.Struct(&existingTeacher); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.Update(r.Context(), existingTeacher)
+	if errs, ok := referenceErrors(err); ok {
+		problem.Validation(w, r, errs)
+		return
+	}
+	if errors.Is(err, repository.ErrVersionMismatch) {
+		writeVersionMismatch(w, r, "Teacher was modified by another request")
+		return
+	} else if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email already exists")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
 	}
 
-	return query, args, queryCount, argsCount
+	w.Header().Set("ETag", versionETag(existingTeacher.Version+1, nil))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(existingTeacher)
 }
 
-func addTeachersHandler(w http.ResponseWriter, r *http.Request) {
-	db, err := sqlconnect.ConnectDb()
-	if err != nil {
-		fmt.Println(err)
+// PatchMany handles PATCH /teachers/, applying an array of merge patches with
+// an "id" member each in one transaction. Nothing is changed if
+// any id is missing.
+func (h *TeachersHandler) PatchMany(w http.ResponseWriter, r *http.Request) {
+	if _, ok := patchMediaType(w, r, mergePatchType); !ok {
 		return
 	}
-	defer db.Close()
 
-	var newTeachers []models.Teacher
-	err = json.NewDecoder(r.Body).Decode(&newTeachers)
+	var updates []mergePatch
+	err := json.NewDecoder(r.Body).Decode(&updates)
 	if err != nil {
-		http.Error(w, "Error parsing JSON", http.StatusBadRequest)
+		log.Println(err)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
 		return
 	}
 
-	stmt, err := db.Prepare(`
-		INSERT INTO teachers (id, first_name, last_name, subject, class, email)
-		VALUES (?,?, ?, ?, ?, ?)
-	`)
-	if err != nil {
-		http.Error(w, "Error preparing statement", http.StatusInternalServerError)
-	}
-	defer stmt.Close()
-
-	addedTeachers := make([]models.Teacher, 0, len(newTeachers))
-	for _, newTeacher := range newTeachers {
-		//check for duplicate email
-		var existingID string
-		err = db.QueryRow("SELECT id FROM teachers WHERE email = ?", newTeacher.Email).Scan(&existingID)
-		if err == nil {
-			//Email already exists
-			http.Error(w, "Email already exists", http.StatusBadRequest)
+	teachers := make([]models.Teacher, 0, len(updates))
+	for i, update := range updates {
+		id, _ := update["id"].(string)
+		if id == "" {
+			problem.Error(w, r, http.StatusBadRequest, fmt.Sprintf("id is required (item %d)", i))
 			return
 		}
 
-		id := uuid.New().String()
-		_, err := stmt.Exec(id, newTeacher.FirstName, newTeacher.LastName, newTeacher.Subject, newTeacher.Class, newTeacher.Email)
+		existingTeacher, err := h.repo.Get(r.Context(), id)
 		if err != nil {
-			http.Error(w, "Error inserting teacher", http.StatusInternalServerError)
+			if errors.Is(err, repository.ErrNotFound) {
+				problem.Error(w, r, http.StatusNotFound, fmt.Sprintf("Teacher not found (item %d)", i))
+				return
+			}
+			log.Println(err)
+			problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
 			return
 		}
-		newTeacher.ID = id
-		addedTeachers = append(addedTeachers, newTeacher)
+
+		if err := patchStruct(&existingTeacher, update, nil); err != nil {
+			writePatchError(w, r, &repository.ItemError{Index: i, Err: err})
+			return
+		}
+		teachers = append(teachers, existingTeacher)
+	}
+
+	if errs := validation.Slice(teachers); len(errs) > 0 {
+		problem.Validation(w, r, errs)
+		return
+	}
+
+	err = h.repo.UpdateMany(r.Context(), teachers)
+	if errs, ok := referenceErrors(err); ok {
+		problem.Validation(w, r, errs)
+		return
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Teacher not found"+itemSuffix(err))
+		return
+	} else if errors.Is(err, repository.ErrVersionMismatch) {
+		problem.Error(w, r, http.StatusConflict, "Teacher was modified by another request"+itemSuffix(err))
+		return
+	} else if errors.Is(err, repository.ErrConflict) {
+		problem.Error(w, r, http.StatusConflict, "Email already exists"+itemSuffix(err))
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to update data")
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
 	response := struct {
 		Status string           `json:"status"`
 		Count  int              `json:"count"`
 		Data   []models.Teacher `json:"data"`
 	}{
 		Status: "success",
-		Count:  len(addedTeachers),
-		Data:   addedTeachers,
+		Count:  len(teachers),
+		Data:   teachers,
 	}
-
 	json.NewEncoder(w).Encode(response)
 }
 
-func updateTeacherHandler(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/teachers/")
-
-	var updatedTeacher models.Teacher
-	err := json.NewDecoder(r.Body).Decode(&updatedTeacher)
+// DeleteMany handles DELETE /teachers/, soft deleting a JSON array of ids in one
+// transaction. Nothing is deleted if any id is missing.
+func (h *TeachersHandler) DeleteMany(w http.ResponseWriter, r *http.Request) {
+	var ids []string
+	err := json.NewDecoder(r.Body).Decode(&ids)
 	if err != nil {
 		log.Println(err)
-		http.Error(w, "Invalid Request Payload", http.StatusBadRequest)
+		problem.ErrorCode(w, r, http.StatusBadRequest, problem.CodeInvalidJSON, "Invalid Request Payload")
+		return
+	}
+	if len(ids) == 0 {
+		problem.Error(w, r, http.StatusBadRequest, "At least one id is required")
 		return
 	}
 
-	db, err := sqlconnect.ConnectDb()
-	if err != nil {
+	err = h.repo.DeleteMany(r.Context(), ids)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Teacher not found"+itemSuffix(err))
+		return
+	} else if err != nil {
 		log.Println(err)
-		http.Error(w, "Error connecting to database", http.StatusInternalServerError)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to delete data")
 		return
 	}
-	defer db.Close()
 
-	var existingTeacher models.Teacher
-	query := "SELECT id, first_name, last_name, email, class, subject FROM teachers WHERE id = ?"
-	err = db.QueryRow(query, idStr).Scan(
-		&existingTeacher.ID,
-		&existingTeacher.FirstName,
-		&existingTeacher.LastName,
-		&existingTeacher.Email,
-		&existingTeacher.Class,
-		&existingTeacher.Subject,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "Teacher not found", http.StatusNotFound)
+	w.Header().Set("Content-Type", "application/json")
+	response := struct {
+		Status string   `json:"status"`
+		Count  int      `json:"count"`
+		IDs    []string `json:"ids"`
+	}{
+		Status: "success",
+		Count:  len(ids),
+		IDs:    ids,
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// Delete handles DELETE /teachers/{id}, soft deleting the teacher. If-Match,
+// when sent, must name the current ETag.
+func (h *TeachersHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+
+	version := 0
+	if r.Header.Get("If-Match") != "" {
+		existingTeacher, ok := h.getForRelation(w, r)
+		if !ok || !checkIfMatch(w, r, existingTeacher.Version) {
 			return
 		}
-		http.Error(w, "Unable to retrieve data", http.StatusNotFound)
+		version = existingTeacher.Version
 	}
 
-	updatedTeacher.ID = existingTeacher.ID
-	updateQuery := `
-	UPDATE teachers 
-	SET first_name = ?, last_name = ?, email = ?, class = ?, subject = ? 
-	WHERE id = ?
-`
-	_, err = db.Exec(
-		updateQuery,
-		updatedTeacher.FirstName,
-		updatedTeacher.LastName,
-		updatedTeacher.Email,
-		updatedTeacher.Class,
-		updatedTeacher.Subject,
-		updatedTeacher.ID,
-	)
-	if err != nil {
-		http.Error(w, "Unable to update data", http.StatusInternalServerError)
+	err := h.repo.Delete(r.Context(), idStr, version)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Teacher not found")
+		return
+	} else if errors.Is(err, repository.ErrVersionMismatch) {
+		writeVersionMismatch(w, r, "Teacher was modified by another request")
+		return
+	} else if err != nil {
+		log.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to delete data")
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedTeacher)
+	response := struct {
+		Status string `json:"status"`
+		ID     string `json:"id"`
+	}{
+		Status: "success",
+		ID:     idStr,
+	}
+	json.NewEncoder(w).Encode(response)
 }
 
-func PatchTeacherHandler(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/teachers/")
+// Restore handles POST /teachers/{id}/restore, undoing a soft delete
+func (h *TeachersHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
 
-	var updates map[string]interface{}
-	err := json.NewDecoder(r.Body).Decode(&updates)
-	if err != nil {
+	err := h.repo.Restore(r.Context(), idStr)
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Deleted teacher not found")
+		return
+	} else if err != nil {
 		log.Println(err)
-		http.Error(w, "Invalid Request Payload", http.StatusBadRequest)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to restore data")
 		return
 	}
 
-	db, err := sqlconnect.ConnectDb()
+	teacher, err := h.repo.Get(r.Context(), idStr)
 	if err != nil {
 		log.Println(err)
-		http.Error(w, "Error connecting to database", http.StatusInternalServerError)
-		return
-	}
-	defer db.Close()
-
-	var existingTeacher models.Teacher
-	query := `
-		SELECT id, first_name, last_name, email, class, subject 
-		FROM teachers 
-		WHERE id = ?
-		`
-	err = db.QueryRow(query, idStr).Scan(
-		&existingTeacher.ID,
-		&existingTeacher.FirstName,
-		&existingTeacher.LastName,
-		&existingTeacher.Email,
-		&existingTeacher.Class,
-		&existingTeacher.Subject,
-	)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			http.Error(w, "Teacher not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Unable to retrieve data", http.StatusNotFound)
-	}
-
-	// Apply updates (NOT IN USE IN REAL WORLD)
-	//for k, v := range updates {
-	//	switch strings.ToLower(k) {
-	//	case "first_name":
-	//		existingTeacher.FirstName = v.(string)
-	//	case "last_name":
-	//		existingTeacher.LastName = v.(string)
-	//	case "email":
-	//		existingTeacher.Email = v.(string)
-	//	case "class":
-	//		existingTeacher.Class = v.(string)
-	//	case "subject":
-	//		existingTeacher.Subject = v.(string)
-	//	}
-	//}
-
-	// Apply updates using reflect
-	teacherVal := reflect.ValueOf(&existingTeacher).Elem()
-	//fmt.Println("teacherVal:", teacherVal)
-	//fmt.Println("teacherVal Type:", teacherVal.Type())
-	//fmt.Println("teacherVal Field 0:", teacherVal.Type().Field(0))
-	//fmt.Println("teacherVal Field 1:", teacherVal.Type().Field(1))
-	//fmt.Println("teacherVal Field 2:", teacherVal.Type().Field(2))
-	teacherType := teacherVal.Type()
-
-	for k, v := range updates {
-		for i := 0; i < teacherVal.NumField(); i++ {
-			field := teacherType.Field(i)
-			//fmt.Println(field.Tag.Get("json"))
-			if field.Tag.Get("json") == k+",omitempty" {
-				if teacherVal.Field(i).CanSet() {
-					fieldVal := teacherVal.Field(i)
-					//fmt.Println("fieldVal", fieldVal)
-					//fmt.Println("reflect.ValueOf(v)", reflect.ValueOf(v))
-					//fmt.Println("teacherVal.Field(i).Type()", teacherVal.Field(i).Type())
-					fieldVal.Set(reflect.ValueOf(v).Convert(teacherVal.Field(i).Type()))
-				}
-			}
-		}
-	}
-
-	query = `
-		UPDATE teachers
-		SET first_name = ?, last_name = ?, email = ?, class = ?, subject = ?
-		WHERE id = ?
-		`
-	_, err = db.Exec(
-		query,
-		existingTeacher.FirstName,
-		existingTeacher.LastName,
-		existingTeacher.Email,
-		existingTeacher.Class,
-		existingTeacher.Subject,
-		idStr,
-	)
-	if err != nil {
-		http.Error(w, "Unable to update data", http.StatusInternalServerError)
+		problem.Error(w, r, http.StatusInternalServerError, "Unable to retrieve data")
 		return
 	}
 
+	w.Header().Set("ETag", versionETag(teacher.Version, nil))
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(existingTeacher)
+	json.NewEncoder(w).Encode(teacher)
 }
 
-func DeleteTeacherHandler(w http.ResponseWriter, r *http.Request) {
-	idStr := strings.TrimPrefix(r.URL.Path, "/teachers/")
+// Students handles GET /teachers/{id}/students, listing the students of the
+// teacher's class with the same pagination and sorting as GET /students/
+func (h *TeachersHandler) Students(w http.ResponseWriter, r *http.Request) {
+	teacher, ok := h.getForRelation(w, r)
+	if !ok {
+		return
+	}
 
-	db, err := sqlconnect.ConnectDb()
+	opts, err := parseListOptions(r, studentFields)
 	if err != nil {
-		log.Println(err)
-		http.Error(w, "Error connecting to database", http.StatusInternalServerError)
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
-	defer db.Close()
+	opts.Filters = append(opts.Filters, repository.Eq("class", teacher.Class))
 
-	result, err := db.Exec("DELETE FROM teachers WHERE id = ?", idStr)
+	fields, err := parseFields(r, studentSelectable)
 	if err != nil {
-		http.Error(w, "Unable to delete data", http.StatusInternalServerError)
+		problem.Error(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	//fmt.Println(result.RowsAffected())
-	rowsAffected, err := result.RowsAffected()
+	studentPage, err := fetchPage(r.Context(), opts, fields, h.students.List)
 	if err != nil {
-		http.Error(w, "Unable to get rows affected", http.StatusInternalServerError)
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
 		return
 	}
 
-	if rowsAffected == 0 {
-		http.Error(w, "Teacher not found", http.StatusNotFound)
+	writePage(w, r, opts, studentPage)
+}
+
+// StudentCount handles GET /teachers/{id}/studentcount
+func (h *TeachersHandler) StudentCount(w http.ResponseWriter, r *http.Request) {
+	teacher, ok := h.getForRelation(w, r)
+	if !ok {
+		return
+	}
+
+	studentCount, err := h.students.Count(r.Context(), []repository.Filter{repository.Eq("class", teacher.Class)})
+	if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
 		return
 	}
 
-	//w.WriteHeader(http.StatusNoContent)
-	w.Header().Set("Content-Type", "application/json")
 	response := struct {
 		Status string `json:"status"`
-		ID     string `json:"id"`
+		Count  int    `json:"count"`
 	}{
 		Status: "success",
-		ID:     idStr,
+		Count:  studentCount,
 	}
+
+	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
+
+// getForRelation loads the teacher named by the id wildcard, writing the
+// error response itself when that fails
+func (h *TeachersHandler) getForRelation(w http.ResponseWriter, r *http.Request) (models.Teacher, bool) {
+	teacher, err := h.repo.Get(r.Context(), r.PathValue("id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		problem.Error(w, r, http.StatusNotFound, "Teacher not found")
+		return teacher, false
+	} else if err != nil {
+		fmt.Println(err)
+		problem.Error(w, r, http.StatusInternalServerError, "Database query error")
+		return teacher, false
+	}
+	return teacher, true
+}
@@ -0,0 +1,108 @@
+package handlers_test
+
+import (
+	"fmt"
+	"go-rest-api/internal/api/handlers"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository/memory"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTeachersHandler returns a handler over empty in-memory repositories
+func newTeachersHandler() *handlers.TeachersHandler {
+	classes := memory.NewClassRepository()
+	teachers := memory.NewTeacherRepository(classes, memory.NewSubjectRepository(), nil)
+	return handlers.NewTeachersHandler(teachers, memory.NewStudentRepository(classes, nil))
+}
+
+// teacherJSON is the body of a teacher with the given names
+func teacherJSON(first, last, email string) string {
+	return fmt.Sprintf(`{"first_name":%q,"last_name":%q,"email":%q,"class":%q,"subject":%q}`,
+		first, last, email, models.Classes[0], models.Subjects[0])
+}
+
+// createTeachers stores the teachers and returns them with their IDs
+func createTeachers(t *testing.T, h *handlers.TeachersHandler, teachers ...string) []models.Teacher {
+	t.Helper()
+	w := serve(h.Create, http.MethodPost, "/teachers/", "["+strings.Join(teachers, ",")+"]")
+	return decode[listResponse[models.Teacher]](t, w, http.StatusCreated).Data
+}
+
+func TestTeachersCreateIsAllOrNothing(t *testing.T) {
+	h := newTeachersHandler()
+	createTeachers(t, h, teacherJSON("Emma", "Stone", "emma@school.test"))
+
+	// Emails are unique regardless of case, as in MySQL
+	w := serve(h.Create, http.MethodPost, "/teachers/", "["+
+		teacherJSON("Liam", "Reed", "liam@school.test")+","+
+		teacherJSON("Emma", "Other", "EMMA@school.test")+"]")
+	expectStatus(t, w, http.StatusConflict)
+
+	w = serve(h.List, http.MethodGet, "/teachers/", "")
+	if got := decode[listResponse[models.Teacher]](t, w, http.StatusOK); got.Count != 1 {
+		t.Fatalf("count = %d after a failed batch, want 1", got.Count)
+	}
+}
+
+func TestTeachersCreatePartial(t *testing.T) {
+	h := newTeachersHandler()
+	createTeachers(t, h, teacherJSON("Emma", "Stone", "emma@school.test"))
+
+	w := serve(h.Create, http.MethodPost, "/teachers/?mode=partial", "["+
+		teacherJSON("Liam", "Reed", "liam@school.test")+","+
+		teacherJSON("Emma", "Other", "EMMA@school.test")+"]")
+	got := decode[multiStatus[models.Teacher]](t, w, http.StatusMultiStatus)
+	if got.Count != 1 || len(got.Results) != 2 {
+		t.Fatalf("got %s, want one of two created", w.Body)
+	}
+	if r := got.Results[0]; r.Index != 0 || r.Status != "created" || r.Data.ID == "" || r.Data.Email != "liam@school.test" {
+		t.Fatalf("results[0] = %+v", r)
+	}
+	if r := got.Results[1]; r.Index != 1 || r.Status != "conflict" || r.Error != "Email already exists" {
+		t.Fatalf("results[1] = %+v", r)
+	}
+
+	w = serve(h.List, http.MethodGet, "/teachers/", "")
+	if got := decode[listResponse[models.Teacher]](t, w, http.StatusOK); got.Count != 2 {
+		t.Fatalf("count = %d after a partial batch, want 2", got.Count)
+	}
+}
+
+func TestTeachersImportReportsEveryFailingLine(t *testing.T) {
+	h := newTeachersHandler()
+	createTeachers(t, h, teacherJSON("Emma", "Stone", "emma@school.test"))
+
+	class, subject := models.Classes[0], models.Subjects[0]
+	body := "first_name,last_name,email,class,subject\n" +
+		"Liam,Reed,liam@school.test," + class + "," + subject + "\n" +
+		"Emma,Other,EMMA@school.test," + class + "," + subject + "\n" +
+		"Ava,Lane,ava@school.test,9Z," + subject + "\n" +
+		"Liam,Again,liam@school.test," + class + "," + subject + "\n"
+	want := []string{"line 3.email", "line 4.class", "line 5.email"}
+
+	for _, target := range []string{"/teachers/import?dry_run=true", "/teachers/import"} {
+		t.Run(target, func(t *testing.T) {
+			w := serve(h.Import, http.MethodPost, target, body, header("Content-Type", "text/csv"))
+			got := decode[problem.Problem](t, w, http.StatusConflict)
+			var fields []string
+			for _, fieldErr := range got.Errors {
+				fields = append(fields, fieldErr.Field)
+			}
+			if !slices.Equal(fields, want) {
+				t.Fatalf("errors on %v, want %v: %s", fields, want, w.Body)
+			}
+			if got.Detail != "Email already exists (line 3, line 5)" {
+				t.Fatalf("detail = %q", got.Detail)
+			}
+		})
+	}
+
+	w := serve(h.List, http.MethodGet, "/teachers/", "")
+	if got := decode[listResponse[models.Teacher]](t, w, http.StatusOK); got.Count != 1 {
+		t.Fatalf("count = %d after a rejected import, want 1", got.Count)
+	}
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"fmt"
+	"go-rest-api/internal/audit"
+	"net"
+	"net/http"
+)
+
+// AuditActor stores the client IP on the request context as the actor of
+// any change the request makes without an authenticated caller, such as a
+// password reset
+func AuditActor(next http.Handler) http.Handler {
+	fmt.Println("AuditActor middleware called")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		next.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), ip)))
+	})
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"fmt"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/auth"
+	"net/http"
+	"strings"
+)
+
+// Authenticate rejects requests without a valid "Authorization: Bearer <token>"
+// header and stores the token claims on the request context.
+func Authenticate(next http.Handler) http.Handler {
+	fmt.Println("Authenticate middleware called")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found || token == "" {
+			problem.Error(w, r, http.StatusUnauthorized, "Authorization token required")
+			return
+		}
+
+		claims, err := auth.ParseToken(token)
+		if err != nil {
+			if err == auth.ErrNoSecret || err == auth.ErrWeakSecret {
+				fmt.Println(err)
+				problem.Error(w, r, http.StatusInternalServerError, "Internal server error")
+				return
+			}
+			problem.Error(w, r, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithClaims(r.Context(), claims)))
+	})
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"fmt"
+	"go-rest-api/internal/api/problem"
+	"go-rest-api/internal/auth"
+	"net/http"
+)
+
+// Authorize checks the caller's role against auth.Permissions for resource
+// and the action of the request method. It must run after Authenticate.
+func Authorize(resource string) func(http.Handler) http.Handler {
+	fmt.Println("Authorize middleware for", resource)
+	return authorize(resource, auth.ActionForMethod)
+}
+
+// AuthorizeAction is like Authorize for a fixed action, for endpoints such as
+// restore that the request method doesn't describe
+func AuthorizeAction(resource string, action auth.Action) func(http.Handler) http.Handler {
+	fmt.Println("Authorize middleware for", resource, action)
+	return authorize(resource, func(string) (auth.Action, bool) {
+		return action, true
+	})
+}
+
+func authorize(resource string, actionFor func(method string) (auth.Action, bool)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := auth.FromContext(r.Context())
+			if !ok {
+				problem.Error(w, r, http.StatusUnauthorized, "Authorization token required")
+				return
+			}
+
+			action, ok := actionFor(r.Method)
+			if !ok || !auth.Allowed(claims.Role, resource, action) {
+				problem.Error(w, r, http.StatusForbidden, fmt.Sprintf("Role %q is not allowed to %s %s", claims.Role, action, resource))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
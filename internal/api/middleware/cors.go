@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"go-rest-api/internal/api/problem"
 	"net/http"
 )
 
@@ -18,12 +19,12 @@ func Cors(next http.Handler) http.Handler {
 		if isOriginAllowed(origin) {
 			w.Header().Set("Access-Control-Allow-Origin", origin)
 		} else {
-			http.Error(w, "Not allowed by CORS", http.StatusForbidden)
+			problem.ErrorCode(w, r, http.StatusForbidden, problem.CodeOriginNotAllowed, "Not allowed by CORS")
 			return
 		}
 
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Expose-Headers", "Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, If-Match, If-None-Match")
+		w.Header().Set("Access-Control-Expose-Headers", "Authorization, X-Request-ID, ETag")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 		w.Header().Set("Access-Control-Max-Age", "3600")
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"go-rest-api/internal/api/problem"
 	"net/http"
 	"strings"
 )
@@ -20,7 +21,11 @@ func Hpp(options HPPOptions) func(http.Handler) http.Handler {
 			fmt.Println("HPP Middleware being returned...")
 			if options.CheckBody && r.Method == http.MethodPost && isCorrectContentType(r, options.CheckBodyOnlyForContentType) {
 				// filter the body params
-				filterBodyParams(r, options.Whitelist)
+				if err := filterBodyParams(r, options.Whitelist); err != nil {
+					fmt.Println(err)
+					problem.Error(w, r, http.StatusBadRequest, "Invalid form body")
+					return
+				}
 			}
 			if options.CheckQuery && r.URL.Query() != nil {
 				// filter the query params
@@ -36,11 +41,10 @@ func isCorrectContentType(r *http.Request, contentType string) bool {
 	return strings.Contains(r.Header.Get("Content-Type"), contentType)
 }
 
-func filterBodyParams(r *http.Request, whitelist []string) {
+func filterBodyParams(r *http.Request, whitelist []string) error {
 	err := r.ParseForm()
 	if err != nil {
-		fmt.Println(err)
-		return
+		return err
 	}
 
 	for k, v := range r.Form {
@@ -52,6 +56,7 @@ func filterBodyParams(r *http.Request, whitelist []string) {
 			delete(r.Form, k)
 		}
 	}
+	return nil
 }
 
 func filterQueryParams(r *http.Request, whitelist []string) {
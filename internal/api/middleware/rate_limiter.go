@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"go-rest-api/internal/api/problem"
 	"net/http"
 	"sync"
 	"time"
@@ -46,7 +47,7 @@ func (rl *rateLimiter) Middleware(next http.Handler) http.Handler {
 		// fmt.Printf("Vistor count from %v is %v\n", visitorIP, rl.visitors[visitorIP])
 
 		if rl.visitors[visitorIP] > rl.limit {
-			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			problem.Error(w, r, http.StatusTooManyRequests, "Too many requests")
 			return
 		}
 		next.ServeHTTP(w, r)
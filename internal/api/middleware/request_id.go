@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"go-rest-api/internal/api/requestid"
+	"net/http"
+)
+
+// maxRequestIDLength bounds client supplied IDs so they can't bloat logs
+const maxRequestIDLength = 128
+
+// RequestID reuses the client's X-Request-ID or generates one, echoes it in
+// the response and stores it on the request context
+func RequestID(next http.Handler) http.Handler {
+	fmt.Println("RequestID middleware called")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = uuid.New().String()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
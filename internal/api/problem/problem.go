@@ -0,0 +1,110 @@
+package problem
+
+import (
+	"encoding/json"
+	"go-rest-api/internal/api/requestid"
+	"go-rest-api/internal/validation"
+	"net/http"
+)
+
+// ContentType is the media type of RFC 7807 error bodies
+const ContentType = "application/problem+json"
+
+// Machine-readable error codes. These are part of the API contract; add new
+// ones rather than changing existing values.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidJSON      = "invalid_json"
+	CodeInvalidCSV       = "invalid_csv"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodePrecondition     = "precondition_failed"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeValidation       = "validation_failed"
+	CodeRateLimited      = "rate_limited"
+	CodeOriginNotAllowed = "origin_not_allowed"
+	CodeInternal         = "internal_error"
+)
+
+var defaultCodes = map[int]string{
+	http.StatusBadRequest:           CodeBadRequest,
+	http.StatusUnauthorized:         CodeUnauthorized,
+	http.StatusForbidden:            CodeForbidden,
+	http.StatusNotFound:             CodeNotFound,
+	http.StatusMethodNotAllowed:     CodeMethodNotAllowed,
+	http.StatusConflict:             CodeConflict,
+	http.StatusPreconditionFailed:   CodePrecondition,
+	http.StatusUnsupportedMediaType: CodeUnsupportedMedia,
+	http.StatusUnprocessableEntity:  CodeValidation,
+	http.StatusTooManyRequests:      CodeRateLimited,
+	http.StatusInternalServerError:  CodeInternal,
+}
+
+// FieldError describes a problem with one field of the request
+type FieldError = validation.FieldError
+
+// Problem is an RFC 7807 problem details object with the extension members
+// code, request_id and errors
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// New returns a Problem for status. An empty code falls back to the default
+// code for the status.
+func New(status int, code, detail string) *Problem {
+	if code == "" {
+		code = defaultCodes[status]
+		if code == "" {
+			code = CodeBadRequest
+			if status >= 500 {
+				code = CodeInternal
+			}
+		}
+	}
+	return &Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// Write sends p as the response, filling in the instance and request ID
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	p.Instance = r.URL.Path
+	p.RequestID = requestid.FromContext(r.Context())
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// Error is the problem equivalent of http.Error, using the default code for
+// status
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, r, New(status, "", detail))
+}
+
+// ErrorCode is like Error with an explicit machine-readable code
+func ErrorCode(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	Write(w, r, New(status, code, detail))
+}
+
+// Validation sends a 422 listing every invalid field
+func Validation(w http.ResponseWriter, r *http.Request, errs []FieldError) {
+	p := New(http.StatusUnprocessableEntity, CodeValidation, "One or more fields are invalid")
+	p.Errors = errs
+	Write(w, r, p)
+}
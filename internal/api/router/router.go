@@ -0,0 +1,72 @@
+package router
+
+import (
+	"go-rest-api/internal/api/problem"
+	"net/http"
+)
+
+// Router is an http.ServeMux using Go 1.22 "METHOD /path/{wildcard}"
+// patterns whose 404 and 405 responses are problem+json like every other
+// error. Handlers read wildcards with r.PathValue.
+type Router struct {
+	mux *http.ServeMux
+}
+
+func New() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+func (rt *Router) Handle(pattern string, handler http.Handler) {
+	rt.mux.Handle(pattern, handler)
+}
+
+func (rt *Router) HandleFunc(pattern string, handler http.HandlerFunc) {
+	rt.mux.HandleFunc(pattern, handler)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	handler, pattern := rt.mux.Handler(r)
+	if pattern != "" {
+		rt.mux.ServeHTTP(w, r)
+		return
+	}
+
+	// No route matched. Let the mux decide between 404 and 405 (it also
+	// computes the Allow header), then rewrite its plain-text body.
+	rec := &statusRecorder{header: make(http.Header)}
+	handler.ServeHTTP(rec, r)
+
+	if allow := rec.header.Get("Allow"); allow != "" {
+		w.Header().Set("Allow", allow)
+	}
+	switch rec.status {
+	case http.StatusMethodNotAllowed:
+		problem.Error(w, r, rec.status, r.Method+" is not allowed on "+r.URL.Path)
+	default:
+		problem.Error(w, r, http.StatusNotFound, "No route matches "+r.URL.Path)
+	}
+}
+
+// statusRecorder captures the status and headers of the mux's fallback
+// handlers and discards their body
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (rec *statusRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *statusRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
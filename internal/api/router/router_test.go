@@ -0,0 +1,74 @@
+package router
+
+import (
+	"encoding/json"
+	"go-rest-api/internal/api/problem"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestRouter() *Router {
+	rt := New()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(r.PathValue("id"))) }
+	rt.HandleFunc("GET /teachers/{id}", ok)
+	rt.HandleFunc("PUT /teachers/{id}", ok)
+	rt.HandleFunc("DELETE /teachers/{id}", ok)
+	return rt
+}
+
+func TestRouterMatches(t *testing.T) {
+	w := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/teachers/42", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "42" {
+		t.Fatalf("got %d %q, want 200 \"42\"", w.Code, w.Body)
+	}
+}
+
+func TestRouterProblems(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		target    string
+		status    int
+		code      string
+		wantAllow []string
+	}{
+		{"unknown path", http.MethodGet, "/nowhere", http.StatusNotFound, problem.CodeNotFound, nil},
+		{"wrong method", http.MethodPost, "/teachers/42", http.StatusMethodNotAllowed, problem.CodeMethodNotAllowed,
+			[]string{http.MethodGet, http.MethodPut, http.MethodDelete}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			newTestRouter().ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+				t.Fatalf("Content-Type = %q, want %q", ct, problem.ContentType)
+			}
+			var p problem.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("decoding %s: %v", w.Body, err)
+			}
+			if p.Status != tt.status || p.Code != tt.code || p.Instance != tt.target {
+				t.Fatalf("got %+v", p)
+			}
+
+			allow := w.Header().Get("Allow")
+			if tt.wantAllow == nil && allow != "" {
+				t.Fatalf("Allow = %q, want none", allow)
+			}
+			for _, method := range tt.wantAllow {
+				if !slices.Contains(strings.Split(allow, ", "), method) {
+					t.Errorf("Allow = %q, missing %s", allow, method)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Package audit builds the audit log entries that repositories write along
+// with every change to teachers, students and execs.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"go-rest-api/internal/api/requestid"
+	"go-rest-api/internal/auth"
+	"go-rest-api/internal/models"
+	"reflect"
+)
+
+type contextKey struct{}
+
+// WithActor returns a copy of ctx naming the actor of changes made without
+// an authenticated caller, such as the client IP or a command
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// Actor returns the username of the authenticated caller, or else the actor
+// stored by WithActor, or "unknown"
+func Actor(ctx context.Context) string {
+	if claims, ok := auth.FromContext(ctx); ok && claims.Username != "" {
+		return claims.Username
+	}
+	if actor, ok := ctx.Value(contextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "unknown"
+}
+
+// NewEntry describes a change to the record id of resource. before and after
+// are the record's model, or nil when it didn't exist. The ID and creation
+// time are left for the repository to assign.
+func NewEntry(ctx context.Context, resource, id, action string, before, after any) (models.AuditEntry, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return models.AuditEntry{}, err
+	}
+	return models.AuditEntry{
+		Actor:      Actor(ctx),
+		Resource:   resource,
+		ResourceID: id,
+		Action:     action,
+		Changes:    changes,
+		RequestID:  requestid.FromContext(ctx),
+	}, nil
+}
+
+// Diff compares the JSON objects of before and after, either of which may be
+// nil, and returns the fields whose value differs
+func Diff(before, after any) (map[string]models.AuditChange, error) {
+	old, err := jsonObject(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := jsonObject(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for name, value := range old {
+		if !reflect.DeepEqual(value, updated[name]) {
+			changes[name] = models.AuditChange{Old: value, New: updated[name]}
+		}
+	}
+	for name, value := range updated {
+		if _, ok := old[name]; !ok {
+			changes[name] = models.AuditChange{New: value}
+		}
+	}
+	return changes, nil
+}
+
+func jsonObject(v any) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var object map[string]interface{}
+	err = json.Unmarshal(data, &object)
+	return object, err
+}
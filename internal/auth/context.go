@@ -0,0 +1,18 @@
+package auth
+
+import "context"
+
+type contextKey string
+
+const claimsKey contextKey = "claims"
+
+// WithClaims returns a copy of ctx carrying the authenticated caller
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey, claims)
+}
+
+// FromContext returns the authenticated caller, if any
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,122 @@
+package auth
+
+import "net/http"
+
+type Action string
+
+const (
+	ActionRead   Action = "read"
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+	// ActionRestore undoes a soft delete. Routes ask for it explicitly; no
+	// HTTP method maps onto it.
+	ActionRestore Action = "restore"
+)
+
+// Exec roles, as assigned by the seeder
+const (
+	RolePrincipal        = "Principal"
+	RoleVicePrincipal    = "Vice Principal"
+	RoleHeadOfDepartment = "Head of Department"
+	RoleAdministrator    = "Administrator"
+	RoleCounselor        = "Counselor"
+	RoleITManager        = "IT Manager"
+	RoleFinancialOfficer = "Financial Officer"
+	RoleHRManager        = "HR Manager"
+)
+
+// AnyRole grants an action to every authenticated caller
+const AnyRole = "*"
+
+// Permissions is the permission matrix: resource -> action -> roles allowed.
+// A resource or action that isn't listed is denied to everyone.
+var Permissions = map[string]map[Action][]string{
+	"teachers": {
+		ActionRead:    {AnyRole},
+		ActionCreate:  {RolePrincipal, RoleVicePrincipal, RoleAdministrator, RoleHeadOfDepartment},
+		ActionUpdate:  {RolePrincipal, RoleVicePrincipal, RoleAdministrator, RoleHeadOfDepartment},
+		ActionDelete:  {RolePrincipal, RoleAdministrator},
+		ActionRestore: {RolePrincipal, RoleAdministrator},
+	},
+	"students": {
+		ActionRead:    {AnyRole},
+		ActionCreate:  {RolePrincipal, RoleVicePrincipal, RoleAdministrator, RoleCounselor},
+		ActionUpdate:  {RolePrincipal, RoleVicePrincipal, RoleAdministrator, RoleCounselor},
+		ActionDelete:  {RolePrincipal, RoleAdministrator},
+		ActionRestore: {RolePrincipal, RoleAdministrator},
+	},
+	"execs": {
+		ActionRead:    {AnyRole},
+		ActionCreate:  {RolePrincipal, RoleHRManager},
+		ActionUpdate:  {RolePrincipal, RoleHRManager},
+		ActionDelete:  {RolePrincipal, RoleHRManager},
+		ActionRestore: {RolePrincipal, RoleHRManager},
+	},
+	"classes": {
+		ActionRead:   {AnyRole},
+		ActionCreate: {RolePrincipal, RoleVicePrincipal, RoleAdministrator},
+		ActionUpdate: {RolePrincipal, RoleVicePrincipal, RoleAdministrator},
+		ActionDelete: {RolePrincipal, RoleAdministrator},
+	},
+	"subjects": {
+		ActionRead:   {AnyRole},
+		ActionCreate: {RolePrincipal, RoleVicePrincipal, RoleAdministrator, RoleHeadOfDepartment},
+		ActionUpdate: {RolePrincipal, RoleVicePrincipal, RoleAdministrator, RoleHeadOfDepartment},
+		ActionDelete: {RolePrincipal, RoleAdministrator},
+	},
+	"search": {
+		ActionRead: {AnyRole},
+	},
+	"stats": {
+		ActionRead: {RolePrincipal, RoleAdministrator, RoleITManager},
+	},
+	"audit": {
+		ActionRead: {RolePrincipal, RoleAdministrator},
+	},
+}
+
+// privilegedRoles hold every permission, so only a Principal may grant or
+// revoke them
+var privilegedRoles = map[string]bool{
+	RolePrincipal:     true,
+	RoleAdministrator: true,
+}
+
+// CanChangeRole reports whether callerRole may change an exec's role from
+// oldRole, empty for a new exec, to newRole. The execs policy decides who
+// may edit execs at all; this only guards the privileged roles.
+func CanChangeRole(callerRole, oldRole, newRole string) bool {
+	if oldRole == newRole {
+		return true
+	}
+	if privilegedRoles[oldRole] || privilegedRoles[newRole] {
+		return callerRole == RolePrincipal
+	}
+	return true
+}
+
+// ActionForMethod maps an HTTP method onto a policy action
+func ActionForMethod(method string) (Action, bool) {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return ActionRead, true
+	case http.MethodPost:
+		return ActionCreate, true
+	case http.MethodPut, http.MethodPatch:
+		return ActionUpdate, true
+	case http.MethodDelete:
+		return ActionDelete, true
+	}
+	return "", false
+}
+
+// Allowed reports whether role may perform action on resource
+func Allowed(role, resource string, action Action) bool {
+	for _, allowed := range Permissions[resource][action] {
+		if allowed == AnyRole || allowed == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token has expired")
+	ErrNoSecret     = errors.New("JWT_SECRET is not set")
+	ErrWeakSecret   = fmt.Errorf("JWT_SECRET must be a random value of at least %d bytes, not the example placeholder", minSecretLength)
+)
+
+// minSecretLength is the shortest JWT_SECRET accepted; HS256 keys should be
+// at least as long as the hash
+const minSecretLength = 32
+
+// placeholderSecret is the example value in .env.example
+const placeholderSecret = "change-me-in-production"
+
+const defaultTokenTTL = 15 * time.Minute
+
+// Claims is the payload of the access tokens issued on login
+type Claims struct {
+	Subject   string `json:"sub"`
+	Username  string `json:"username"`
+	Role      string `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// SignToken issues an HS256 JWT for the given exec. The key is read from
+// JWT_SECRET and the lifetime from JWT_EXPIRES_IN (a time.Duration string,
+// 15m when unset).
+func SignToken(userID, username, role string) (string, error) {
+	secret, err := secret()
+	if err != nil {
+		return "", err
+	}
+
+	ttl := defaultTokenTTL
+	if v := os.Getenv("JWT_EXPIRES_IN"); v != "" {
+		ttl, err = time.ParseDuration(v)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	now := time.Now()
+	claims := Claims{
+		Subject:   userID,
+		Username:  username,
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	header, err := json.Marshal(tokenHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encoding.EncodeToString(header) + "." + encoding.EncodeToString(payload)
+	return signingInput + "." + encoding.EncodeToString(sign(signingInput, secret)), nil
+}
+
+// ParseToken verifies the signature and expiry of a token and returns its claims
+func ParseToken(token string) (*Claims, error) {
+	secret, err := secret()
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	headerBytes, err := encoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var header tokenHeader
+	if err := json.Unmarshal(headerBytes, &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !hmac.Equal(signature, sign(parts[0]+"."+parts[1], secret)) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := encoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredToken
+	}
+
+	return &claims, nil
+}
+
+func sign(signingInput string, secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+// CheckSecret reports whether JWT_SECRET can sign tokens. Servers call it at
+// startup so a missing or weak key stops them before any token is issued.
+func CheckSecret() error {
+	_, err := secret()
+	return err
+}
+
+func secret() ([]byte, error) {
+	s := os.Getenv("JWT_SECRET")
+	if s == "" {
+		return nil, ErrNoSecret
+	}
+	if s == placeholderSecret || len(s) < minSecretLength {
+		return nil, ErrWeakSecret
+	}
+	return []byte(s), nil
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckSecret(t *testing.T) {
+	tests := []struct {
+		secret string
+		want   error
+	}{
+		{"", ErrNoSecret},
+		{placeholderSecret, ErrWeakSecret},
+		{strings.Repeat("x", minSecretLength-1), ErrWeakSecret},
+		{strings.Repeat("x", minSecretLength), nil},
+	}
+	for _, tt := range tests {
+		t.Setenv("JWT_SECRET", tt.secret)
+		if err := CheckSecret(); err != tt.want {
+			t.Errorf("CheckSecret() with %d byte secret = %v, want %v", len(tt.secret), err, tt.want)
+		}
+	}
+}
+
+func TestSignTokenRefusesWeakSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", placeholderSecret)
+	if _, err := SignToken("id", "user", RolePrincipal); err != ErrWeakSecret {
+		t.Fatalf("SignToken() error = %v, want %v", err, ErrWeakSecret)
+	}
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	t.Setenv("JWT_SECRET", strings.Repeat("k", minSecretLength))
+	token, err := SignToken("id", "user", RolePrincipal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "id" || claims.Role != RolePrincipal {
+		t.Fatalf("claims = %+v", claims)
+	}
+}
@@ -0,0 +1,61 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sender delivers a plain-text email
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+// LogSender writes emails to the standard logger. Only meant for development.
+type LogSender struct{}
+
+func (LogSender) Send(to, subject, body string) error {
+	log.Printf("Mail to: %s\nSubject: %s\n\n%s\n", to, subject, body)
+	return nil
+}
+
+// FileSender writes each email to its own file in Dir. Only meant for
+// development.
+type FileSender struct {
+	Dir string
+}
+
+func (s FileSender) Send(to, subject, body string) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d_%s.eml", time.Now().UnixNano(), strings.NewReplacer("@", "_at_", "/", "_").Replace(to))
+	content := fmt.Sprintf("To: %s\r\nSubject: %s\r\n\r\n%s\r\n", to, subject, body)
+	return os.WriteFile(filepath.Join(s.Dir, name), []byte(content), 0o600)
+}
+
+// NewSenderFromEnv returns the Sender chosen by MAIL_SENDER: "file" for a
+// FileSender writing to MAIL_DIR, or "log" for a LogSender. Mail holds live
+// password reset tokens, so logging it must be asked for; with MAIL_SENDER
+// unset a FileSender is used if MAIL_DIR is set, and it is an error
+// otherwise.
+func NewSenderFromEnv() (Sender, error) {
+	dir := os.Getenv("MAIL_DIR")
+	switch kind := os.Getenv("MAIL_SENDER"); kind {
+	case "log":
+		log.Println("Warning: MAIL_SENDER=log writes password reset tokens to the log")
+		return LogSender{}, nil
+	case "file", "":
+		if dir == "" {
+			return nil, errors.New("MAIL_DIR must be set, or MAIL_SENDER=log to log mail instead")
+		}
+		return FileSender{Dir: dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_SENDER %q; use file or log", kind)
+	}
+}
@@ -0,0 +1,36 @@
+package mail
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewSenderFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		sender  string
+		dir     string
+		want    Sender
+		wantErr bool
+	}{
+		{"file", "file", "mail_out", FileSender{Dir: "mail_out"}, false},
+		{"dir alone", "", "mail_out", FileSender{Dir: "mail_out"}, false},
+		{"log on request", "log", "", LogSender{}, false},
+		{"nothing set", "", "", nil, true},
+		{"file without dir", "file", "", nil, true},
+		{"unknown", "smtp", "mail_out", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAIL_SENDER", tt.sender)
+			t.Setenv("MAIL_DIR", tt.dir)
+			got, err := NewSenderFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want one: %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// versionTable records which migrations have been applied
+const versionTable = "schema_migrations"
+
+type Migrator struct {
+	db  *sql.DB
+	dir string
+	out io.Writer
+}
+
+// New returns a Migrator applying the migrations in dir. Progress is written
+// to stdout.
+func New(db *sql.DB, dir string) *Migrator {
+	return &Migrator{db: db, dir: dir, out: os.Stdout}
+}
+
+func (m *Migrator) ensureVersionTable(ctx context.Context) error {
+	_, err := m.db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+versionTable+` (
+			version BIGINT PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)`)
+	return err
+}
+
+// applied returns the applied versions and when they were applied
+func (m *Migrator) applied(ctx context.Context) (map[int64]string, error) {
+	if err := m.ensureVersionTable(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version, COALESCE(applied_at, '') FROM "+versionTable)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int64]string)
+	for rows.Next() {
+		var version int64
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	return versions, rows.Err()
+}
+
+// Up applies every pending migration in version order
+func (m *Migrator) Up(ctx context.Context) error {
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.run(ctx, migration, true); err != nil {
+			if len(applied) == 0 {
+				return fmt.Errorf("%v\nno migrations are recorded as applied; if the schema was created by hand, record it with: migrate baseline <version>", err)
+			}
+			return err
+		}
+		count++
+	}
+
+	if count == 0 {
+		fmt.Fprintln(m.out, "No pending migrations")
+	}
+	return nil
+}
+
+// Baseline records every migration up to and including version as applied
+// without running it, for databases whose schema was created by hand before
+// the version table existed. version must be that of a migration file.
+func (m *Migrator) Baseline(ctx context.Context, version int64) error {
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	var baseline []*Migration
+	for _, migration := range migrations {
+		if migration.Version > version {
+			break
+		}
+		found = migration.Version == version
+		if _, ok := applied[migration.Version]; !ok {
+			baseline = append(baseline, migration)
+		}
+	}
+	if !found {
+		return fmt.Errorf("no migration with version %d in %s", version, m.dir)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, migration := range baseline {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO "+versionTable+" (version) VALUES (?)", migration.Version); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, migration := range baseline {
+		fmt.Fprintf(m.out, "MARK %s\n", filepath.Base(migration.Path))
+	}
+	if len(baseline) == 0 {
+		fmt.Fprintln(m.out, "Already applied")
+	}
+	return nil
+}
+
+// Down rolls back the most recently applied migration
+func (m *Migrator) Down(ctx context.Context) error {
+	migration, err := m.latestApplied(ctx)
+	if err != nil {
+		return err
+	}
+	return m.run(ctx, migration, false)
+}
+
+// Redo rolls back and re-applies the most recently applied migration
+func (m *Migrator) Redo(ctx context.Context) error {
+	migration, err := m.latestApplied(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.run(ctx, migration, false); err != nil {
+		return err
+	}
+	return m.run(ctx, migration, true)
+}
+
+// Status prints every migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) error {
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(m.out, "%-24s %s\n", "Applied At", "Migration")
+	for _, migration := range migrations {
+		appliedAt, ok := applied[migration.Version]
+		if !ok {
+			appliedAt = "Pending"
+		}
+		fmt.Fprintf(m.out, "%-24s %s\n", appliedAt, filepath.Base(migration.Path))
+	}
+	return nil
+}
+
+func (m *Migrator) latestApplied(ctx context.Context) (*Migration, error) {
+	migrations, err := Load(m.dir)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[migrations[i].Version]; ok {
+			return migrations[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no applied migrations to roll back")
+}
+
+// execer is satisfied by both *sql.DB and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// run applies one direction of a migration and updates the version table.
+// MySQL commits DDL statements implicitly, so the transaction only makes
+// data changes atomic; a failed DDL migration may need manual cleanup.
+func (m *Migrator) run(ctx context.Context, migration *Migration, up bool) error {
+	statements, direction := migration.Up, "up"
+	record := "INSERT INTO " + versionTable + " (version) VALUES (?)"
+	if !up {
+		statements, direction = migration.Down, "down"
+		record = "DELETE FROM " + versionTable + " WHERE version = ?"
+	}
+
+	start := time.Now()
+	apply := func(ex execer) error {
+		for _, stmt := range statements {
+			if _, err := ex.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("%s %s: %v\n%s", filepath.Base(migration.Path), direction, err, stmt)
+			}
+		}
+		_, err := ex.ExecContext(ctx, record, migration.Version)
+		return err
+	}
+
+	if migration.UseTx {
+		tx, err := m.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := apply(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	} else if err := apply(m.db); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(m.out, "OK   %s %s (%s)\n", direction, filepath.Base(migration.Path), time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// Create writes an empty migration named <timestamp>_<name>.sql into dir and
+// returns its path
+func Create(dir, name string) (string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if name == "" {
+		return "", fmt.Errorf("migration name is required")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("%s_%s.sql", time.Now().UTC().Format("20060102150405"), name))
+	content := "-- +goose Up\n\n-- +goose Down\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
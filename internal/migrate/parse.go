@@ -0,0 +1,177 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is one goose-style SQL file named <version>_<name>.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Path    string
+	Up      []string
+	Down    []string
+	// UseTx is false when the file is annotated with -- +goose NO TRANSACTION
+	UseTx bool
+}
+
+const annotationPrefix = "-- +goose "
+
+// Load reads and parses every .sql migration in dir, ordered by version
+func Load(dir string) ([]*Migration, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]*Migration, 0, len(paths))
+	seen := make(map[int64]string)
+	for _, path := range paths {
+		m, err := parseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if other, ok := seen[m.Version]; ok {
+			return nil, fmt.Errorf("duplicate migration version %d: %s and %s", m.Version, other, path)
+		}
+		seen[m.Version] = path
+		migrations = append(migrations, m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+func parseFile(path string) (*Migration, error) {
+	base := filepath.Base(path)
+	versionStr, name, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), "_")
+	if !ok {
+		return nil, fmt.Errorf("migration %s: file name must be <version>_<name>.sql", base)
+	}
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("migration %s: invalid version: %v", base, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := &Migration{Version: version, Name: name, Path: path, UseTx: true}
+	if err := parse(f, m); err != nil {
+		return nil, fmt.Errorf("migration %s: %v", base, err)
+	}
+	return m, nil
+}
+
+// parse splits the Up and Down sections into individual statements.
+// Statements end with a semicolon at the end of a line, except inside a
+// StatementBegin/StatementEnd block, which is kept as a single statement.
+func parse(r io.Reader, m *Migration) error {
+	var (
+		section    *[]string
+		buf        strings.Builder
+		inBlock    bool
+		foundUp    bool
+		lineNumber int
+	)
+
+	flush := func() {
+		stmt := strings.TrimSpace(buf.String())
+		buf.Reset()
+		if stmt != "" && section != nil {
+			*section = append(*section, stmt)
+		}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if annotation, ok := strings.CutPrefix(trimmed, annotationPrefix); ok {
+			switch strings.TrimSpace(annotation) {
+			case "Up":
+				flush()
+				section = &m.Up
+				foundUp = true
+			case "Down":
+				flush()
+				section = &m.Down
+			case "StatementBegin":
+				flush()
+				inBlock = true
+			case "StatementEnd":
+				if !inBlock {
+					return fmt.Errorf("line %d: StatementEnd without StatementBegin", lineNumber)
+				}
+				inBlock = false
+				flush()
+			case "NO TRANSACTION":
+				m.UseTx = false
+			default:
+				return fmt.Errorf("line %d: unknown annotation %q", lineNumber, trimmed)
+			}
+			continue
+		}
+
+		if section == nil || (!inBlock && (trimmed == "" || strings.HasPrefix(trimmed, "--"))) {
+			continue
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		if !inBlock && strings.HasSuffix(strings.TrimSpace(stripComment(line)), ";") {
+			flush()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if inBlock {
+		return fmt.Errorf("StatementBegin without StatementEnd")
+	}
+	if !foundUp {
+		return fmt.Errorf("missing -- +goose Up annotation")
+	}
+	flush()
+	return nil
+}
+
+// stripComment returns line without a trailing -- or # comment, so that
+// "DROP TABLE t; -- why" still ends a statement. Comment markers inside
+// quoted strings and identifiers are left alone.
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '#':
+			return line[:i]
+		case c == '-' && strings.HasPrefix(line[i:], "--") && (i+2 == len(line) || line[i+2] == ' ' || line[i+2] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
@@ -0,0 +1,131 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		wantUp   []string
+		wantDown []string
+		wantTx   bool
+	}{
+		{
+			name: "up and down",
+			sql: "-- +goose Up\n" +
+				"CREATE TABLE a (id INT);\n" +
+				"-- a comment line\n" +
+				"CREATE TABLE b (\n  id INT\n);\n" +
+				"-- +goose Down\n" +
+				"DROP TABLE b;\nDROP TABLE a;\n",
+			wantUp:   []string{"CREATE TABLE a (id INT);", "CREATE TABLE b (\n  id INT\n);"},
+			wantDown: []string{"DROP TABLE b;", "DROP TABLE a;"},
+			wantTx:   true,
+		},
+		{
+			name: "statement block",
+			sql: "-- +goose Up\n" +
+				"-- +goose StatementBegin\n" +
+				"CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN\n  SET NEW.id = 1;\nEND;\n" +
+				"-- +goose StatementEnd\n" +
+				"-- +goose Down\n" +
+				"DROP TRIGGER t;\n",
+			wantUp:   []string{"CREATE TRIGGER t BEFORE INSERT ON a FOR EACH ROW BEGIN\n  SET NEW.id = 1;\nEND;"},
+			wantDown: []string{"DROP TRIGGER t;"},
+			wantTx:   true,
+		},
+		{
+			name:     "no transaction",
+			sql:      "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX i ON a (id);\n",
+			wantUp:   []string{"CREATE INDEX i ON a (id);"},
+			wantDown: nil,
+			wantTx:   false,
+		},
+		{
+			name: "trailing comments",
+			sql: "-- +goose Up\n" +
+				"ALTER TABLE a ADD COLUMN b INT; -- for reports\n" +
+				"INSERT INTO a (note) VALUES ('x -- y;'); # seed\n" +
+				"UPDATE a SET note = 'not the end; -- here'\n  WHERE id = 1;\n",
+			wantUp: []string{
+				"ALTER TABLE a ADD COLUMN b INT; -- for reports",
+				"INSERT INTO a (note) VALUES ('x -- y;'); # seed",
+				"UPDATE a SET note = 'not the end; -- here'\n  WHERE id = 1;",
+			},
+			wantTx: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Migration{UseTx: true}
+			if err := parse(strings.NewReader(tt.sql), m); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(m.Up, tt.wantUp) {
+				t.Errorf("Up = %q, want %q", m.Up, tt.wantUp)
+			}
+			if !reflect.DeepEqual(m.Down, tt.wantDown) {
+				t.Errorf("Down = %q, want %q", m.Down, tt.wantDown)
+			}
+			if m.UseTx != tt.wantTx {
+				t.Errorf("UseTx = %v, want %v", m.UseTx, tt.wantTx)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+	}{
+		{"missing up", "CREATE TABLE a (id INT);\n"},
+		{"unknown annotation", "-- +goose Up\n-- +goose Sideways\n"},
+		{"unterminated block", "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n"},
+		{"stray block end", "-- +goose Up\n-- +goose StatementEnd\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := parse(strings.NewReader(tt.sql), &Migration{}); err == nil {
+				t.Fatal("got no error")
+			}
+		})
+	}
+}
+
+func TestParseFileName(t *testing.T) {
+	dir := t.TempDir()
+	body := []byte("-- +goose Up\nSELECT 1;\n")
+
+	tests := []struct {
+		file    string
+		wantErr bool
+	}{
+		{"20250430071253_create_tables.sql", false},
+		{"create_tables.sql", true},
+		{"20250430071253.sql", true},
+		{"2025x_create_tables.sql", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, body, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := parseFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want one: %v", err, tt.wantErr)
+			}
+			if err == nil && (m.Version != 20250430071253 || m.Name != "create_tables") {
+				t.Fatalf("got version %d name %q", m.Version, m.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,7 @@
+package models
+
+// Class is a row of the classes table. Teachers and students refer to it by
+// name.
+type Class struct {
+	Name string `json:"name,omitempty" validate:"required,max=255"`
+}
@@ -0,0 +1,21 @@
+package models
+
+// Exec is a row of the executives table. Password is only ever read from
+// request bodies; handlers never select it back out of the database, so it is
+// omitted from every response. The reset token fields are never serialized.
+type Exec struct {
+	ID                 string `json:"id,omitempty"`
+	FirstName          string `json:"first_name,omitempty" validate:"required,max=255"`
+	LastName           string `json:"last_name,omitempty" validate:"required,max=255"`
+	Email              string `json:"email,omitempty" validate:"required,max=255,email"`
+	Username           string `json:"username,omitempty" validate:"required,max=255"`
+	Password           string `json:"password,omitempty"`
+	LastPasswordChange string `json:"last_password_change,omitempty"`
+	UserCreationTime   string `json:"user_creation_time,omitempty"`
+	PasswordResetToken string `json:"-"`
+	ResetTokenExpiry   string `json:"-"`
+	Role               string `json:"role,omitempty" validate:"required,max=255,oneof=role"`
+	UserInactive       bool   `json:"user_inactive"`
+	// DeletedAt is set while the exec is soft deleted
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
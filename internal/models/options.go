@@ -0,0 +1,28 @@
+package models
+
+import "go-rest-api/internal/validation"
+
+// Subjects created by the classes and subjects migration. The subjects table
+// is authoritative; these are the defaults of the in-memory repositories.
+var Subjects = []string{
+	"Mathematics", "Physics", "Chemistry", "Biology", "History",
+	"Geography", "English", "Literature", "Computer Science", "Art",
+	"Music", "Physical Education", "Economics", "Psychology", "Philosophy",
+}
+
+// Classes created by the classes and subjects migration. The classes table is
+// authoritative; these are the defaults of the in-memory repositories.
+var Classes = []string{
+	"1A", "1B", "1C", "2A", "2B", "2C", "3A", "3B", "3C",
+	"4A", "4B", "4C", "5A", "5B", "5C", "6A", "6B", "6C",
+}
+
+// Roles that can be assigned to executives
+var Roles = []string{
+	"Principal", "Vice Principal", "Head of Department", "Administrator",
+	"Counselor", "IT Manager", "Financial Officer", "HR Manager",
+}
+
+func init() {
+	validation.RegisterSet("role", Roles)
+}
@@ -0,0 +1,19 @@
+package models
+
+// Kinds of person a SearchResult can be
+const (
+	SearchTeacher = "teacher"
+	SearchStudent = "student"
+	SearchExec    = "exec"
+)
+
+// SearchResult is one teacher, student or exec matching a search. Higher
+// scores are better matches.
+type SearchResult struct {
+	Type      string  `json:"type"`
+	ID        string  `json:"id"`
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	Email     string  `json:"email"`
+	Score     float64 `json:"score"`
+}
@@ -0,0 +1,11 @@
+package models
+
+type Student struct {
+	ID        string `json:"id,omitempty"`
+	FirstName string `json:"first_name,omitempty" validate:"required,max=255"`
+	LastName  string `json:"last_name,omitempty" validate:"required,max=255"`
+	Email     string `json:"email,omitempty" validate:"required,max=255,email"`
+	Class     string `json:"class,omitempty" validate:"required,max=255"`
+	// DeletedAt is set while the student is soft deleted
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
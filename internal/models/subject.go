@@ -0,0 +1,6 @@
+package models
+
+// Subject is a row of the subjects table. Teachers refer to it by name.
+type Subject struct {
+	Name string `json:"name,omitempty" validate:"required,max=255"`
+}
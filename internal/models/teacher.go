@@ -2,9 +2,14 @@ package models
 
 type Teacher struct {
 	ID        string `json:"id,omitempty"`
-	FirstName string `json:"first_name,omitempty"`
-	LastName  string `json:"last_name,omitempty"`
-	Email     string `json:"email,omitempty"`
-	Class     string `json:"class,omitempty"`
-	Subject   string `json:"subject,omitempty"`
+	FirstName string `json:"first_name,omitempty" validate:"required,max=255"`
+	LastName  string `json:"last_name,omitempty" validate:"required,max=255"`
+	Email     string `json:"email,omitempty" validate:"required,max=255,email"`
+	Class     string `json:"class,omitempty" validate:"required,max=255"`
+	Subject   string `json:"subject,omitempty" validate:"required,max=255"`
+	// DeletedAt is set while the teacher is soft deleted
+	DeletedAt string `json:"deleted_at,omitempty"`
+	// Version counts the updates of the row for optimistic locking. It is
+	// sent as the ETag rather than in bodies.
+	Version int `json:"-"`
 }
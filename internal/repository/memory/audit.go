@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"go-rest-api/internal/audit"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AuditRepository is an in-memory repository.AuditRepository. The in-memory
+// teacher, student and exec repositories given one log their changes to it,
+// right after making them rather than atomically with them.
+type AuditRepository struct {
+	store store[models.AuditEntry]
+
+	mu     sync.Mutex
+	nextID int64
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{store: newStore[models.AuditEntry]()}
+}
+
+func (repo *AuditRepository) List(_ context.Context, opts repository.ListOptions) ([]models.AuditEntry, int, error) {
+	entries, total := repo.store.list(opts)
+	return entries, total, nil
+}
+
+// snapshotter returns the record id, or nil if there is none, as logged
+type snapshotter func(id string) any
+
+// snapshots returns a snapshotter for the records of s, passed through view
+// if it is not nil
+func snapshots[T any](s *store[T], view func(T) T) snapshotter {
+	return func(id string) any {
+		item, ok := s.snapshot(id)
+		if !ok {
+			return nil
+		}
+		if view != nil {
+			item = view(item)
+		}
+		return item
+	}
+}
+
+// changer is the signature of the audited change method of each repository
+type changer func(ctx context.Context, ids []string, action string, fn func() error) error
+
+// change runs fn, which changes the records ids of resource, and logs the
+// effect on each if fn succeeds. A nil repository logs nothing.
+func (repo *AuditRepository) change(ctx context.Context, resource string, ids []string, action string, snapshot snapshotter, fn func() error) error {
+	if repo == nil {
+		return fn()
+	}
+
+	before := make([]any, len(ids))
+	for i, id := range ids {
+		before[i] = snapshot(id)
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if err := repo.record(ctx, resource, id, action, before[i], snapshot(id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// record logs a change of the record id of resource from before to after
+func (repo *AuditRepository) record(ctx context.Context, resource, id, action string, before, after any) error {
+	if repo == nil {
+		return nil
+	}
+
+	entry, err := audit.NewEntry(ctx, resource, id, action, before, after)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	repo.nextID++
+	entry.ID = repo.nextID
+	entry.CreatedAt = time.Now().UTC().Format(time.DateTime)
+	return repo.store.insert([]string{strconv.FormatInt(entry.ID, 10)}, []models.AuditEntry{entry})
+}
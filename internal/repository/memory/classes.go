@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+)
+
+// ClassRepository is an in-memory repository.ClassRepository holding the
+// default models.Classes. Pass it to NewTeacherRepository and
+// NewStudentRepository so their class references are enforced.
+type ClassRepository struct {
+	names *lookup
+}
+
+func NewClassRepository() *ClassRepository {
+	return &ClassRepository{names: newLookup(models.Classes)}
+}
+
+func (repo *ClassRepository) List(_ context.Context) ([]models.Class, error) {
+	names := repo.names.list()
+	classes := make([]models.Class, len(names))
+	for i, name := range names {
+		classes[i] = models.Class{Name: name}
+	}
+	return classes, nil
+}
+
+func (repo *ClassRepository) Get(_ context.Context, name string) (models.Class, error) {
+	if !repo.names.has(name) {
+		return models.Class{}, repository.ErrNotFound
+	}
+	return models.Class{Name: name}, nil
+}
+
+func (repo *ClassRepository) Create(_ context.Context, classes []models.Class) error {
+	names := make([]string, len(classes))
+	for i, class := range classes {
+		names[i] = class.Name
+	}
+	return repo.names.insert(names)
+}
+
+func (repo *ClassRepository) Rename(ctx context.Context, name, newName string) error {
+	return repo.names.rename(ctx, name, newName)
+}
+
+func (repo *ClassRepository) Delete(_ context.Context, name string) error {
+	return repo.names.delete(name)
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"sync"
+	"time"
+)
+
+// ExecRepository is an in-memory repository.ExecRepository for tests and
+// local development. Changes are logged to audit unless it is nil.
+type ExecRepository struct {
+	store store[models.Exec]
+	audit *AuditRepository
+
+	mu          sync.Mutex
+	resetExpiry map[string]time.Time
+}
+
+func NewExecRepository(audit *AuditRepository) *ExecRepository {
+	return &ExecRepository{
+		store:       newStore[models.Exec](),
+		audit:       audit,
+		resetExpiry: make(map[string]time.Time),
+	}
+}
+
+// change runs fn, which changes the execs ids, and logs it as action. The
+// log never sees their secrets.
+func (repo *ExecRepository) change(ctx context.Context, ids []string, action string, fn func() error) error {
+	return repo.audit.change(ctx, "execs", ids, action, snapshots(&repo.store, public), fn)
+}
+
+// public strips the secrets that the repository never hands out
+func public(exec models.Exec) models.Exec {
+	exec.Password = ""
+	exec.PasswordResetToken = ""
+	exec.ResetTokenExpiry = ""
+	return exec
+}
+
+func (repo *ExecRepository) List(_ context.Context, opts repository.ListOptions) ([]models.Exec, int, error) {
+	execs, total := repo.store.list(opts)
+	result := make([]models.Exec, len(execs))
+	for i, exec := range execs {
+		result[i] = public(exec)
+	}
+	return result, total, nil
+}
+
+func (repo *ExecRepository) Get(_ context.Context, id string, _ ...string) (models.Exec, error) {
+	exec, err := repo.store.get(id)
+	return public(exec), err
+}
+
+func (repo *ExecRepository) GetByEmail(_ context.Context, email string) (models.Exec, error) {
+	exec, err := repo.store.find("email", email)
+	return public(exec), err
+}
+
+func (repo *ExecRepository) GetByUsername(_ context.Context, username string) (models.Exec, error) {
+	exec, err := repo.store.find("username", username)
+	exec.PasswordResetToken = ""
+	exec.ResetTokenExpiry = ""
+	return exec, err
+}
+
+func (repo *ExecRepository) Create(ctx context.Context, execs []models.Exec) ([]models.Exec, error) {
+	now := time.Now().UTC().Format(time.DateTime)
+	ids := make([]string, len(execs))
+	stored := make([]models.Exec, len(execs))
+	added := make([]models.Exec, len(execs))
+	for i, exec := range execs {
+		ids[i] = uuid.New().String()
+		exec.ID = ids[i]
+		exec.LastPasswordChange = now
+		exec.UserCreationTime = now
+		stored[i] = exec
+		added[i] = public(exec)
+	}
+	err := repo.change(ctx, ids, models.AuditCreate, func() error {
+		return repo.store.insert(ids, stored, "email", "username")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+func (repo *ExecRepository) Update(ctx context.Context, exec models.Exec) error {
+	err := repo.UpdateMany(ctx, []models.Exec{exec})
+	return errors.Unwrap(err)
+}
+
+func (repo *ExecRepository) UpdateMany(ctx context.Context, execs []models.Exec) error {
+	ids := make([]string, len(execs))
+	merged := make([]models.Exec, len(execs))
+	for i, exec := range execs {
+		existing, err := repo.store.get(exec.ID)
+		if err != nil {
+			return &repository.ItemError{Index: i, Err: err}
+		}
+		existing.FirstName = exec.FirstName
+		existing.LastName = exec.LastName
+		existing.Email = exec.Email
+		existing.Username = exec.Username
+		existing.Role = exec.Role
+		existing.UserInactive = exec.UserInactive
+		ids[i] = exec.ID
+		merged[i] = existing
+	}
+	return repo.change(ctx, ids, models.AuditUpdate, func() error {
+		return repo.store.replaceMany(ids, merged, "email", "username")
+	})
+}
+
+func (repo *ExecRepository) Delete(ctx context.Context, id string) error {
+	return repo.change(ctx, []string{id}, models.AuditDelete, func() error {
+		return repo.store.delete(id)
+	})
+}
+
+func (repo *ExecRepository) DeleteMany(ctx context.Context, ids []string) error {
+	return repo.change(ctx, ids, models.AuditDelete, func() error {
+		return repo.store.deleteMany(ids)
+	})
+}
+
+func (repo *ExecRepository) Restore(ctx context.Context, id string) error {
+	return repo.change(ctx, []string{id}, models.AuditRestore, func() error {
+		return repo.store.restore(id)
+	})
+}
+
+func (repo *ExecRepository) Purge(ctx context.Context, olderThan time.Duration) (int, error) {
+	purged := repo.store.purge(olderThan)
+	for _, exec := range purged {
+		if err := repo.audit.record(ctx, "execs", exec.ID, models.AuditPurge, public(exec), nil); err != nil {
+			return 0, err
+		}
+	}
+	return len(purged), nil
+}
+
+func (repo *ExecRepository) SetResetToken(_ context.Context, id, tokenHash string, ttl time.Duration) error {
+	exec, err := repo.store.get(id)
+	if err != nil {
+		return err
+	}
+
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	exec.PasswordResetToken = tokenHash
+	repo.resetExpiry[id] = time.Now().Add(ttl)
+	return repo.store.replace(id, exec)
+}
+
+// ResetPassword logs the new password change time, but not the password
+func (repo *ExecRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) error {
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+
+	exec, err := repo.store.findFunc(func(e models.Exec) bool {
+		return tokenHash != "" && e.PasswordResetToken == tokenHash
+	})
+	if err != nil || time.Now().After(repo.resetExpiry[exec.ID]) {
+		return repository.ErrNotFound
+	}
+
+	exec.Password = passwordHash
+	exec.LastPasswordChange = time.Now().UTC().Format(time.DateTime)
+	exec.PasswordResetToken = ""
+	delete(repo.resetExpiry, exec.ID)
+	return repo.change(ctx, []string{exec.ID}, models.AuditUpdate, func() error {
+		return repo.store.replace(exec.ID, exec)
+	})
+}
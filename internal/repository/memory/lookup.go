@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"go-rest-api/internal/repository"
+	"sort"
+	"sync"
+)
+
+// lookup is an in-memory table of names such as classes. Repositories whose
+// records refer to it register a reference, so that renames cascade and names
+// in use can't be deleted, as the MySQL repositories do.
+type lookup struct {
+	mu    sync.RWMutex
+	names map[string]bool
+	refs  []reference
+}
+
+// reference is one column that refers to a lookup
+type reference struct {
+	uses   func(name string) bool
+	rename func(ctx context.Context, name, newName string) error
+}
+
+func newLookup(names []string) *lookup {
+	l := &lookup{names: make(map[string]bool, len(names))}
+	for _, name := range names {
+		l.names[name] = true
+	}
+	return l
+}
+
+func (l *lookup) refer(ref reference) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refs = append(l.refs, ref)
+}
+
+func (l *lookup) list() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	names := make([]string, 0, len(l.names))
+	for name := range l.names {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (l *lookup) has(name string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.names[name]
+}
+
+// insert adds all names or, if any already exists, none of them
+func (l *lookup) insert(names []string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seen := make(map[string]bool, len(names))
+	for i, name := range names {
+		if l.names[name] || seen[name] {
+			return &repository.ItemError{Index: i, Err: repository.ErrConflict}
+		}
+		seen[name] = true
+	}
+	for _, name := range names {
+		l.names[name] = true
+	}
+	return nil
+}
+
+func (l *lookup) rename(ctx context.Context, name, newName string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.names[name] {
+		return repository.ErrNotFound
+	}
+	if name == newName {
+		return nil
+	}
+	if l.names[newName] {
+		return repository.ErrConflict
+	}
+
+	delete(l.names, name)
+	l.names[newName] = true
+	for _, ref := range l.refs {
+		if err := ref.rename(ctx, name, newName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *lookup) delete(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.names[name] {
+		return repository.ErrNotFound
+	}
+	for _, ref := range l.refs {
+		if ref.uses(name) {
+			return repository.ErrInUse
+		}
+	}
+	delete(l.names, name)
+	return nil
+}
+
+// check returns a *repository.ReferenceError if value isn't in the lookup
+func (l *lookup) check(field, value string) error {
+	if !l.has(value) {
+		return &repository.ReferenceError{Field: field, Value: value}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"context"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SearchRepository is an in-memory repository.SearchRepository over the
+// in-memory teacher, student and exec repositories. It approximates MySQL's
+// natural language full-text search: each query word found among the words
+// of a person's names and email scores a point.
+type SearchRepository struct {
+	teachers *TeacherRepository
+	students *StudentRepository
+	execs    *ExecRepository
+}
+
+func NewSearchRepository(teachers *TeacherRepository, students *StudentRepository, execs *ExecRepository) *SearchRepository {
+	return &SearchRepository{teachers: teachers, students: students, execs: execs}
+}
+
+func (repo *SearchRepository) Search(_ context.Context, query string, limit, offset int) ([]models.SearchResult, int, error) {
+	terms := searchWords(query)
+	results := make([]models.SearchResult, 0)
+	add := func(result models.SearchResult) {
+		result.Score = searchScore(terms, result.FirstName, result.LastName, result.Email)
+		if result.Score > 0 {
+			results = append(results, result)
+		}
+	}
+
+	teachers, _ := repo.teachers.store.list(repository.ListOptions{})
+	for _, t := range teachers {
+		add(models.SearchResult{Type: models.SearchTeacher, ID: t.ID, FirstName: t.FirstName, LastName: t.LastName, Email: t.Email})
+	}
+	students, _ := repo.students.store.list(repository.ListOptions{})
+	for _, s := range students {
+		add(models.SearchResult{Type: models.SearchStudent, ID: s.ID, FirstName: s.FirstName, LastName: s.LastName, Email: s.Email})
+	}
+	execs, _ := repo.execs.store.list(repository.ListOptions{})
+	for _, e := range execs {
+		add(models.SearchResult{Type: models.SearchExec, ID: e.ID, FirstName: e.FirstName, LastName: e.LastName, Email: e.Email})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ID < b.ID
+	})
+
+	total := len(results)
+	start := min(offset, total)
+	end := total
+	if limit > 0 {
+		end = min(start+limit, total)
+	}
+	return results[start:end], total, nil
+}
+
+// searchWords splits s into lower case words at anything that isn't a
+// letter or digit, the way the full-text parser splits "emma.smith@x.com"
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func searchScore(terms []string, fields ...string) float64 {
+	var words []string
+	for _, field := range fields {
+		words = append(words, searchWords(field)...)
+	}
+
+	var score float64
+	for _, term := range terms {
+		for _, word := range words {
+			if word == term {
+				score++
+			}
+		}
+	}
+	return score
+}
@@ -0,0 +1,579 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// store keeps records in insertion order, keyed by ID. Fields are addressed by
+// their json tag name, which matches the database column names.
+//
+// Records with a deleted_at field are soft deleted: delete sets it, and until
+// restore clears it every method but list with IncludeDeleted treats the
+// record as missing. Deleted records still hold their unique values.
+type store[T any] struct {
+	mu    sync.RWMutex
+	items map[string]T
+	order []string
+}
+
+func newStore[T any]() store[T] {
+	return store[T]{items: make(map[string]T)}
+}
+
+func (s *store[T]) list(opts repository.ListOptions) ([]T, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := make([]T, 0)
+	for _, id := range s.order {
+		item := s.items[id]
+		if (opts.IncludeDeleted || !isDeleted(item)) && matches(item, opts.Filters) {
+			matched = append(matched, item)
+		}
+	}
+
+	sortFields := opts.Sort
+	if opts.Keyset != nil {
+		sortFields = opts.KeysetSort()
+	}
+	if len(sortFields) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			return compareItems(matched[i], matched[j], sortFields) < 0
+		})
+	}
+
+	if opts.Keyset != nil {
+		return keysetPage(matched, sortFields, opts.Keyset, opts.Limit), -1
+	}
+
+	total := len(matched)
+	start := min(opts.Offset, total)
+	end := total
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, total)
+	}
+	return matched[start:end], total
+}
+
+// keysetPage returns up to limit of the sorted items after, or for a
+// backward keyset before, the keyset position
+func keysetPage[T any](sorted []T, sortFields []repository.SortField, keyset *repository.Keyset, limit int) []T {
+	// position is the index of the first item after the keyset
+	position := 0
+	if len(keyset.Values) > 0 {
+		position = sort.Search(len(sorted), func(i int) bool {
+			return compareKey(sorted[i], keyset.Values, sortFields) > 0
+		})
+	}
+
+	start, end := position, len(sorted)
+	if keyset.Backward {
+		// Skip the keyset row itself if it is still there
+		start, end = 0, position
+		if end > 0 && compareKey(sorted[end-1], keyset.Values, sortFields) == 0 {
+			end--
+		}
+	}
+	if limit > 0 {
+		if keyset.Backward {
+			start = max(end-limit, 0)
+		} else {
+			end = min(start+limit, end)
+		}
+	}
+	return sorted[start:end]
+}
+
+// compareItems orders a and b by the sort fields, returning -1, 0 or 1
+func compareItems(a, b any, sortFields []repository.SortField) int {
+	for _, sf := range sortFields {
+		if c := compareValues(fieldValue(a, sf.Field), fieldValue(b, sf.Field), sf.Order); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareKey orders item against the key values of a keyset
+func compareKey(item any, key []string, sortFields []repository.SortField) int {
+	for i, sf := range sortFields {
+		if c := compareValues(fieldValue(item, sf.Field), key[i], sf.Order); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues compares integers numerically, as MySQL does for numeric
+// columns, and anything else as strings ignoring case, as MySQL's default
+// collation does
+func compareValues(a, b, order string) int {
+	c := compareFold(a, b)
+	x, errA := strconv.ParseInt(a, 10, 64)
+	y, errB := strconv.ParseInt(b, 10, 64)
+	if errA == nil && errB == nil {
+		c = cmp.Compare(x, y)
+	}
+	if order == "desc" {
+		return -c
+	}
+	return c
+}
+
+func (s *store[T]) get(id string) (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.items[id]
+	if !ok || isDeleted(item) {
+		var zero T
+		return zero, repository.ErrNotFound
+	}
+	return item, nil
+}
+
+// find returns the first record whose field equals value
+func (s *store[T]) find(field, value string) (T, error) {
+	return s.findFunc(func(item T) bool {
+		return strings.EqualFold(fieldValue(item, field), value)
+	})
+}
+
+// findFunc returns the first record for which match returns true
+func (s *store[T]) findFunc(match func(T) bool) (T, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, id := range s.order {
+		if !isDeleted(s.items[id]) && match(s.items[id]) {
+			return s.items[id], nil
+		}
+	}
+	var zero T
+	return zero, repository.ErrNotFound
+}
+
+// insert adds all items or, if any of the unique fields clash with an
+// existing record or with another item of the same call, none of them
+func (s *store[T]) insert(ids []string, items []T, unique ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items = slices.Clone(items)
+	for i := range items {
+		setField(&items[i], "deleted_at", "")
+	}
+	if err := s.checkInsertLocked(ids, items, unique); err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		s.items[ids[i]] = item
+		s.order = append(s.order, ids[i])
+	}
+	return nil
+}
+
+// checkEach reports, without storing anything, every item that inserting
+// the items one after another would fail on: those check rejects and those
+// whose unique fields clash with a record or an earlier item that didn't
+// fail. The result joins an *ItemError for each, or is nil.
+func (s *store[T]) checkEach(items []T, check func(T) error, unique ...string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var errs []error
+	var accepted []T
+	for i, item := range items {
+		err := check(item)
+		if err == nil && (s.conflicts("", item, unique) || slices.ContainsFunc(accepted, func(other T) bool {
+			return sameUnique(other, item, unique)
+		})) {
+			err = repository.ErrConflict
+		}
+		if err != nil {
+			errs = append(errs, &repository.ItemError{Index: i, Err: err})
+			continue
+		}
+		accepted = append(accepted, item)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *store[T]) checkInsertLocked(ids []string, items []T, unique []string) error {
+	for i, item := range items {
+		if s.conflicts(ids[i], item, unique) {
+			return &repository.ItemError{Index: i, Err: repository.ErrConflict}
+		}
+		for j := 0; j < i; j++ {
+			if sameUnique(items[j], item, unique) {
+				return &repository.ItemError{Index: i, Err: repository.ErrConflict}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *store[T]) replace(id string, item T, unique ...string) error {
+	return s.replaceChecked(id, item, nil, unique...)
+}
+
+// replaceChecked is replace with a check of the stored record made under the
+// same lock. check may adjust the new item, e.g. to bump a version.
+func (s *store[T]) replaceChecked(id string, item T, check func(current T, next *T) error, unique ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.items[id]
+	if !ok || isDeleted(current) {
+		return repository.ErrNotFound
+	}
+	setField(&item, "deleted_at", "")
+	if check != nil {
+		if err := check(current, &item); err != nil {
+			return err
+		}
+	}
+	if s.conflicts(id, item, unique) {
+		return repository.ErrConflict
+	}
+	s.items[id] = item
+	return nil
+}
+
+// replaceMany replaces every item, or none of them if any is missing or
+// clashes on a unique field
+func (s *store[T]) replaceMany(ids []string, items []T, unique ...string) error {
+	return s.replaceManyChecked(ids, items, nil, unique...)
+}
+
+// replaceManyChecked is replaceMany with a check of each stored record, as
+// for replaceChecked
+func (s *store[T]) replaceManyChecked(ids []string, items []T, check func(current T, next *T) error, unique ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items = slices.Clone(items)
+	for i, id := range ids {
+		current, ok := s.items[id]
+		if !ok || isDeleted(current) {
+			return &repository.ItemError{Index: i, Err: repository.ErrNotFound}
+		}
+		setField(&items[i], "deleted_at", "")
+		if check != nil {
+			if err := check(current, &items[i]); err != nil {
+				return &repository.ItemError{Index: i, Err: err}
+			}
+		}
+	}
+
+	// Items are applied in order, so a clash within the batch is reported on
+	// the later item, as sequential UPDATEs in MySQL would
+	previous := make(map[string]T, len(ids))
+	for i, id := range ids {
+		if _, ok := previous[id]; !ok {
+			previous[id] = s.items[id]
+		}
+		s.items[id] = items[i]
+		if s.conflicts(id, items[i], unique) {
+			for id, item := range previous {
+				s.items[id] = item
+			}
+			return &repository.ItemError{Index: i, Err: repository.ErrConflict}
+		}
+	}
+	return nil
+}
+
+// deleteMany soft deletes every id, or none of them if any is missing
+func (s *store[T]) deleteMany(ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		if item, ok := s.items[id]; !ok || isDeleted(item) || seen[id] {
+			return &repository.ItemError{Index: i, Err: repository.ErrNotFound}
+		}
+		seen[id] = true
+	}
+
+	now := time.Now().UTC().Format(time.DateTime)
+	for _, id := range ids {
+		item := s.items[id]
+		setField(&item, "deleted_at", now)
+		s.items[id] = item
+	}
+	return nil
+}
+
+func (s *store[T]) delete(id string) error {
+	return s.deleteChecked(id, nil)
+}
+
+// deleteChecked is delete with a check of the stored record made under the
+// same lock
+func (s *store[T]) deleteChecked(id string, check func(current T) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.items[id]
+	if !ok || isDeleted(current) {
+		return repository.ErrNotFound
+	}
+	if check != nil {
+		if err := check(current); err != nil {
+			return err
+		}
+	}
+	setField(&current, "deleted_at", time.Now().UTC().Format(time.DateTime))
+	s.items[id] = current
+	return nil
+}
+
+// restore undeletes a soft deleted record
+func (s *store[T]) restore(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[id]
+	if !ok || !isDeleted(item) {
+		return repository.ErrNotFound
+	}
+	setField(&item, "deleted_at", "")
+	s.items[id] = item
+	return nil
+}
+
+// purge removes the records soft deleted more than olderThan ago for good,
+// returning them
+func (s *store[T]) purge(olderThan time.Duration) []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().UTC().Add(-olderThan).Format(time.DateTime)
+	order := s.order[:0]
+	var purged []T
+	for _, id := range s.order {
+		if item := s.items[id]; isDeleted(item) && fieldValue(item, "deleted_at") < cutoff {
+			delete(s.items, id)
+			purged = append(purged, item)
+			continue
+		}
+		order = append(order, id)
+	}
+	s.order = order
+	return purged
+}
+
+// snapshot returns the record id, deleted or not, for the audit log
+func (s *store[T]) snapshot(id string) (T, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	item, ok := s.items[id]
+	return item, ok
+}
+
+// reference lets a lookup see and rename the values of field. Renames go
+// through change, the audited change method of the owning repository, and
+// apply touch, if not nil, to every record they rewrite.
+func (s *store[T]) reference(field string, change changer, touch func(*T)) reference {
+	return reference{
+		uses: func(name string) bool {
+			return s.uses(field, name)
+		},
+		rename: func(ctx context.Context, name, newName string) error {
+			return change(ctx, s.matching(field, name), models.AuditUpdate, func() error {
+				s.rewrite(field, name, newName, touch)
+				return nil
+			})
+		},
+	}
+}
+
+// matching returns the IDs of the records, deleted or not, whose field is
+// set to value
+func (s *store[T]) matching(field, value string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ids []string
+	for _, id := range s.order {
+		if fieldValue(s.items[id], field) == value {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// uses reports whether any record, deleted or not, has field set to value
+func (s *store[T]) uses(field, value string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, item := range s.items {
+		if fieldValue(item, field) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// rewrite sets field to newValue on every record where it equals value,
+// applying touch, if not nil, to each
+func (s *store[T]) rewrite(field, value, newValue string, touch func(*T)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, item := range s.items {
+		if fieldValue(item, field) == value {
+			setField(&item, field, newValue)
+			if touch != nil {
+				touch(&item)
+			}
+			s.items[id] = item
+		}
+	}
+}
+
+// conflicts must be called with the lock held
+func (s *store[T]) conflicts(id string, item T, unique []string) bool {
+	for otherID, other := range s.items {
+		if otherID != id && sameUnique(other, item, unique) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDeleted(item any) bool {
+	return fieldValue(item, "deleted_at") != ""
+}
+
+func sameUnique(a, b any, unique []string) bool {
+	for _, field := range unique {
+		if strings.EqualFold(fieldValue(a, field), fieldValue(b, field)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matches(item any, filters []repository.Filter) bool {
+	for _, filter := range filters {
+		if !matchFilter(fieldValue(item, filter.Field), filter) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchFilter compares value the way the SQL generated by the mysql
+// package does, ignoring case like MySQL's default collation. Ordering
+// operators compare strings, which is correct for the time.DateTime
+// timestamps this package stores.
+func matchFilter(value string, filter repository.Filter) bool {
+	inValues := func() bool {
+		return slices.ContainsFunc(filter.Values, func(v string) bool {
+			return strings.EqualFold(v, value)
+		})
+	}
+	switch filter.Op {
+	case repository.OpIn:
+		return inValues()
+	case repository.OpNin:
+		return !inValues()
+	}
+
+	want := filter.Values[0]
+	switch filter.Op {
+	case repository.OpEq:
+		return strings.EqualFold(value, want)
+	case repository.OpNe:
+		return !strings.EqualFold(value, want)
+	case repository.OpLike:
+		return likeMatch(value, want)
+	case repository.OpGt:
+		return compareFold(value, want) > 0
+	case repository.OpGte:
+		return compareFold(value, want) >= 0
+	case repository.OpLt:
+		return compareFold(value, want) < 0
+	case repository.OpLte:
+		return compareFold(value, want) <= 0
+	}
+	panic("memory: unknown filter operator " + string(filter.Op))
+}
+
+// compareFold compares strings ignoring case
+func compareFold(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// likeMatch reports whether value matches a SQL LIKE pattern, ignoring case
+// as MySQL's default collation does. % matches any run of characters, _ any
+// single character and a backslash escapes the next one.
+func likeMatch(value, pattern string) bool {
+	var expr strings.Builder
+	expr.WriteString("(?is)^")
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			expr.WriteString(".*")
+		case r == '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.MustCompile(expr.String()).MatchString(value)
+}
+
+// fieldValue returns the string form of the struct field tagged json:"name"
+func fieldValue(item any, name string) string {
+	val := reflect.ValueOf(item)
+	if i := fieldIndex(val.Type(), name); i >= 0 {
+		return fmt.Sprint(val.Field(i).Interface())
+	}
+	return ""
+}
+
+// setField sets the string field tagged json:"name" of the struct item
+// points to
+func setField(item any, name, value string) {
+	val := reflect.ValueOf(item).Elem()
+	if i := fieldIndex(val.Type(), name); i >= 0 {
+		val.Field(i).SetString(value)
+	}
+}
+
+func fieldIndex(typ reflect.Type, name string) int {
+	for i := 0; i < typ.NumField(); i++ {
+		tag, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+		if tag == name {
+			return i
+		}
+	}
+	return -1
+}
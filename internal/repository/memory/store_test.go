@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"errors"
+	"go-rest-api/internal/repository"
+	"testing"
+)
+
+func TestMatchFilterIgnoresCaseLikeMySQL(t *testing.T) {
+	tests := []struct {
+		value  string
+		op     repository.Operator
+		values []string
+		want   bool
+	}{
+		{"Emma@School.test", repository.OpEq, []string{"emma@school.test"}, true},
+		{"Emma", repository.OpNe, []string{"EMMA"}, false},
+		{"Emma", repository.OpIn, []string{"liam", "emma"}, true},
+		{"Emma", repository.OpNin, []string{"EMMA"}, false},
+		{"Stone", repository.OpLike, []string{"st%"}, true},
+		{"Stone", repository.OpLike, []string{"_TONE"}, true},
+		{"Stone", repository.OpLike, []string{"st"}, false},
+		{"50%", repository.OpLike, []string{`50\%`}, true},
+		{"505", repository.OpLike, []string{`50\%`}, false},
+		{"a.b", repository.OpLike, []string{"a.b"}, true},
+		{"axb", repository.OpLike, []string{"a.b"}, false},
+		{"beta", repository.OpGt, []string{"ALPHA"}, true},
+		{"Alpha", repository.OpGte, []string{"alpha"}, true},
+		{"Alpha", repository.OpLt, []string{"alpha"}, false},
+		{"2025-05-01 10:00:00", repository.OpLte, []string{"2025-05-01 10:00:00"}, true},
+	}
+	for _, tt := range tests {
+		filter := repository.Filter{Field: "name", Op: tt.op, Values: tt.values}
+		if got := matchFilter(tt.value, filter); got != tt.want {
+			t.Errorf("%q %s %q = %v, want %v", tt.value, tt.op, tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestStoreUniqueFieldsIgnoreCase(t *testing.T) {
+	type item struct {
+		Email     string `json:"email"`
+		DeletedAt string `json:"deleted_at"`
+	}
+	s := newStore[item]()
+	if err := s.insert([]string{"a"}, []item{{Email: "emma@school.test"}}, "email"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.find("email", "EMMA@school.test"); err != nil {
+		t.Fatalf("find ignoring case: %v", err)
+	}
+	err := s.insert([]string{"b"}, []item{{Email: "Emma@School.test"}}, "email")
+	if !errors.Is(err, repository.ErrConflict) {
+		t.Fatalf("insert = %v, want a conflict", err)
+	}
+}
@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"time"
+)
+
+// StudentRepository is an in-memory repository.StudentRepository for tests
+// and local development. Classes must exist in the given repository, which
+// sees renames and deletes through to the students. Changes are logged to
+// audit unless it is nil.
+type StudentRepository struct {
+	store   store[models.Student]
+	classes *lookup
+	audit   *AuditRepository
+}
+
+func NewStudentRepository(classes *ClassRepository, audit *AuditRepository) *StudentRepository {
+	repo := &StudentRepository{
+		store:   newStore[models.Student](),
+		classes: classes.names,
+		audit:   audit,
+	}
+	classes.names.refer(repo.store.reference("class", repo.change, nil))
+	return repo
+}
+
+// change runs fn, which changes the students ids, and logs it as action
+func (repo *StudentRepository) change(ctx context.Context, ids []string, action string, fn func() error) error {
+	return repo.audit.change(ctx, "students", ids, action, snapshots(&repo.store, nil), fn)
+}
+
+func (repo *StudentRepository) List(_ context.Context, opts repository.ListOptions) ([]models.Student, int, error) {
+	students, total := repo.store.list(opts)
+	return students, total, nil
+}
+
+func (repo *StudentRepository) Count(_ context.Context, filters []repository.Filter) (int, error) {
+	_, total := repo.store.list(repository.ListOptions{Filters: filters})
+	return total, nil
+}
+
+func (repo *StudentRepository) Get(_ context.Context, id string, _ ...string) (models.Student, error) {
+	return repo.store.get(id)
+}
+
+func (repo *StudentRepository) Create(ctx context.Context, students []models.Student) ([]models.Student, error) {
+	ids := make([]string, len(students))
+	added := make([]models.Student, len(students))
+	for i, student := range students {
+		if err := repo.classes.check("class", student.Class); err != nil {
+			return nil, &repository.ItemError{Index: i, Err: err}
+		}
+		ids[i] = uuid.New().String()
+		student.ID = ids[i]
+		added[i] = student
+	}
+	err := repo.change(ctx, ids, models.AuditCreate, func() error {
+		return repo.store.insert(ids, added, "email")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+func (repo *StudentRepository) CheckCreate(_ context.Context, students []models.Student) error {
+	return repo.store.checkEach(students, func(student models.Student) error {
+		return repo.classes.check("class", student.Class)
+	}, "email")
+}
+
+func (repo *StudentRepository) CreateEach(ctx context.Context, students []models.Student) ([]models.Student, []error) {
+	created := make([]models.Student, len(students))
+	errs := make([]error, len(students))
+	for i, student := range students {
+		added, err := repo.Create(ctx, []models.Student{student})
+		if err != nil {
+			created[i], errs[i] = student, errors.Unwrap(err)
+			continue
+		}
+		created[i] = added[0]
+	}
+	return created, errs
+}
+
+func (repo *StudentRepository) Update(ctx context.Context, student models.Student) error {
+	if err := repo.classes.check("class", student.Class); err != nil {
+		return err
+	}
+	return repo.change(ctx, []string{student.ID}, models.AuditUpdate, func() error {
+		return repo.store.replace(student.ID, student, "email")
+	})
+}
+
+func (repo *StudentRepository) UpdateMany(ctx context.Context, students []models.Student) error {
+	ids := make([]string, len(students))
+	for i, student := range students {
+		if err := repo.classes.check("class", student.Class); err != nil {
+			return &repository.ItemError{Index: i, Err: err}
+		}
+		ids[i] = student.ID
+	}
+	return repo.change(ctx, ids, models.AuditUpdate, func() error {
+		return repo.store.replaceMany(ids, students, "email")
+	})
+}
+
+func (repo *StudentRepository) Delete(ctx context.Context, id string) error {
+	return repo.change(ctx, []string{id}, models.AuditDelete, func() error {
+		return repo.store.delete(id)
+	})
+}
+
+func (repo *StudentRepository) DeleteMany(ctx context.Context, ids []string) error {
+	return repo.change(ctx, ids, models.AuditDelete, func() error {
+		return repo.store.deleteMany(ids)
+	})
+}
+
+func (repo *StudentRepository) Restore(ctx context.Context, id string) error {
+	return repo.change(ctx, []string{id}, models.AuditRestore, func() error {
+		return repo.store.restore(id)
+	})
+}
+
+func (repo *StudentRepository) Purge(ctx context.Context, olderThan time.Duration) (int, error) {
+	purged := repo.store.purge(olderThan)
+	for _, student := range purged {
+		if err := repo.audit.record(ctx, "students", student.ID, models.AuditPurge, student, nil); err != nil {
+			return 0, err
+		}
+	}
+	return len(purged), nil
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+)
+
+// SubjectRepository is an in-memory repository.SubjectRepository holding the
+// default models.Subjects. Pass it to NewTeacherRepository so teacher
+// subjects are enforced.
+type SubjectRepository struct {
+	names *lookup
+}
+
+func NewSubjectRepository() *SubjectRepository {
+	return &SubjectRepository{names: newLookup(models.Subjects)}
+}
+
+func (repo *SubjectRepository) List(_ context.Context) ([]models.Subject, error) {
+	names := repo.names.list()
+	subjects := make([]models.Subject, len(names))
+	for i, name := range names {
+		subjects[i] = models.Subject{Name: name}
+	}
+	return subjects, nil
+}
+
+func (repo *SubjectRepository) Get(_ context.Context, name string) (models.Subject, error) {
+	if !repo.names.has(name) {
+		return models.Subject{}, repository.ErrNotFound
+	}
+	return models.Subject{Name: name}, nil
+}
+
+func (repo *SubjectRepository) Create(_ context.Context, subjects []models.Subject) error {
+	names := make([]string, len(subjects))
+	for i, subject := range subjects {
+		names[i] = subject.Name
+	}
+	return repo.names.insert(names)
+}
+
+func (repo *SubjectRepository) Rename(ctx context.Context, name, newName string) error {
+	return repo.names.rename(ctx, name, newName)
+}
+
+func (repo *SubjectRepository) Delete(_ context.Context, name string) error {
+	return repo.names.delete(name)
+}
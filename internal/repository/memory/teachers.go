@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"time"
+)
+
+// TeacherRepository is an in-memory repository.TeacherRepository for tests
+// and local development. Classes and subjects must exist in the given
+// repositories, which see renames and deletes through to the teachers.
+// Changes are logged to audit unless it is nil.
+type TeacherRepository struct {
+	store    store[models.Teacher]
+	classes  *lookup
+	subjects *lookup
+	audit    *AuditRepository
+}
+
+func NewTeacherRepository(classes *ClassRepository, subjects *SubjectRepository, audit *AuditRepository) *TeacherRepository {
+	repo := &TeacherRepository{
+		store:    newStore[models.Teacher](),
+		classes:  classes.names,
+		subjects: subjects.names,
+		audit:    audit,
+	}
+	classes.names.refer(repo.store.reference("class", repo.change, bumpVersion))
+	subjects.names.refer(repo.store.reference("subject", repo.change, bumpVersion))
+	return repo
+}
+
+// change runs fn, which changes the teachers ids, and logs it as action
+func (repo *TeacherRepository) change(ctx context.Context, ids []string, action string, fn func() error) error {
+	return repo.audit.change(ctx, "teachers", ids, action, snapshots(&repo.store, nil), fn)
+}
+
+func (repo *TeacherRepository) checkReferences(teacher models.Teacher) error {
+	if err := repo.classes.check("class", teacher.Class); err != nil {
+		return err
+	}
+	return repo.subjects.check("subject", teacher.Subject)
+}
+
+func (repo *TeacherRepository) List(_ context.Context, opts repository.ListOptions) ([]models.Teacher, int, error) {
+	teachers, total := repo.store.list(opts)
+	return teachers, total, nil
+}
+
+func (repo *TeacherRepository) Count(_ context.Context, filters []repository.Filter) (int, error) {
+	_, total := repo.store.list(repository.ListOptions{Filters: filters})
+	return total, nil
+}
+
+func (repo *TeacherRepository) Get(_ context.Context, id string, _ ...string) (models.Teacher, error) {
+	return repo.store.get(id)
+}
+
+func (repo *TeacherRepository) Create(ctx context.Context, teachers []models.Teacher) ([]models.Teacher, error) {
+	ids := make([]string, len(teachers))
+	added := make([]models.Teacher, len(teachers))
+	for i, teacher := range teachers {
+		if err := repo.checkReferences(teacher); err != nil {
+			return nil, &repository.ItemError{Index: i, Err: err}
+		}
+		ids[i] = uuid.New().String()
+		teacher.ID = ids[i]
+		teacher.Version = 1
+		added[i] = teacher
+	}
+	err := repo.change(ctx, ids, models.AuditCreate, func() error {
+		return repo.store.insert(ids, added, "email")
+	})
+	if err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+func (repo *TeacherRepository) CheckCreate(_ context.Context, teachers []models.Teacher) error {
+	return repo.store.checkEach(teachers, repo.checkReferences, "email")
+}
+
+func (repo *TeacherRepository) CreateEach(ctx context.Context, teachers []models.Teacher) ([]models.Teacher, []error) {
+	created := make([]models.Teacher, len(teachers))
+	errs := make([]error, len(teachers))
+	for i, teacher := range teachers {
+		added, err := repo.Create(ctx, []models.Teacher{teacher})
+		if err != nil {
+			created[i], errs[i] = teacher, errors.Unwrap(err)
+			continue
+		}
+		created[i] = added[0]
+	}
+	return created, errs
+}
+
+func (repo *TeacherRepository) Update(ctx context.Context, teacher models.Teacher) error {
+	if err := repo.checkReferences(teacher); err != nil {
+		return err
+	}
+	return repo.change(ctx, []string{teacher.ID}, models.AuditUpdate, func() error {
+		return repo.store.replaceChecked(teacher.ID, teacher, nextVersion, "email")
+	})
+}
+
+func (repo *TeacherRepository) UpdateMany(ctx context.Context, teachers []models.Teacher) error {
+	ids := make([]string, len(teachers))
+	for i, teacher := range teachers {
+		if err := repo.checkReferences(teacher); err != nil {
+			return &repository.ItemError{Index: i, Err: err}
+		}
+		ids[i] = teacher.ID
+	}
+	return repo.change(ctx, ids, models.AuditUpdate, func() error {
+		return repo.store.replaceManyChecked(ids, teachers, nextVersion, "email")
+	})
+}
+
+func (repo *TeacherRepository) Delete(ctx context.Context, id string, version int) error {
+	return repo.change(ctx, []string{id}, models.AuditDelete, func() error {
+		return repo.store.deleteChecked(id, func(current models.Teacher) error {
+			if version != 0 && version != current.Version {
+				return repository.ErrVersionMismatch
+			}
+			return nil
+		})
+	})
+}
+
+// bumpVersion marks a teacher rewritten by a lookup rename as changed
+func bumpVersion(teacher *models.Teacher) {
+	teacher.Version++
+}
+
+// nextVersion checks the version of an update against the stored teacher
+// and gives the update the following one
+func nextVersion(current models.Teacher, next *models.Teacher) error {
+	if next.Version != 0 && next.Version != current.Version {
+		return repository.ErrVersionMismatch
+	}
+	next.Version = current.Version + 1
+	return nil
+}
+
+func (repo *TeacherRepository) DeleteMany(ctx context.Context, ids []string) error {
+	return repo.change(ctx, ids, models.AuditDelete, func() error {
+		return repo.store.deleteMany(ids)
+	})
+}
+
+func (repo *TeacherRepository) Restore(ctx context.Context, id string) error {
+	return repo.change(ctx, []string{id}, models.AuditRestore, func() error {
+		return repo.store.restore(id)
+	})
+}
+
+func (repo *TeacherRepository) Purge(ctx context.Context, olderThan time.Duration) (int, error) {
+	purged := repo.store.purge(olderThan)
+	for _, teacher := range purged {
+		if err := repo.audit.record(ctx, "teachers", teacher.ID, models.AuditPurge, teacher, nil); err != nil {
+			return 0, err
+		}
+	}
+	return len(purged), nil
+}
@@ -0,0 +1,275 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go-rest-api/internal/audit"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"time"
+)
+
+// auditedTable is a table whose changes are written to the audit log. Rows
+// are read back through columns, which must leave out secrets. A versioned
+// table has a version column that every change must increment.
+type auditedTable[T any] struct {
+	table     string
+	resource  string
+	columns   func(*T) []column
+	versioned bool
+}
+
+var (
+	teachersTable = auditedTable[models.Teacher]{table: "teachers", resource: "teachers", columns: teacherColumns, versioned: true}
+	studentsTable = auditedTable[models.Student]{table: "students", resource: "students", columns: studentColumns}
+	execsTable    = auditedTable[models.Exec]{table: "executives", resource: "execs", columns: execColumns}
+)
+
+// snapshot reads the row id, deleted or not, locking it for the rest of the
+// transaction. It returns nil if there is no such row.
+func (t auditedTable[T]) snapshot(ctx context.Context, q querier, id string) (*T, error) {
+	var row T
+	list, dests := selectColumns(t.columns(&row), nil)
+	err := q.QueryRowContext(ctx, "SELECT "+list+" FROM "+t.table+" WHERE id = ? FOR UPDATE", id).Scan(dests...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// change runs fn, which changes the row id, and logs the effect in the audit
+// log. fn receives the row as it was, or nil. q should be a transaction so
+// the change and its entry commit together.
+//
+// A create has no row before it, and locking the freshly generated id with
+// FOR UPDATE would take a gap lock that deadlocks concurrent inserts, so
+// AuditCreate skips the first snapshot.
+func (t auditedTable[T]) change(ctx context.Context, q querier, id, action string, fn func(before *T) error) error {
+	var before *T
+	if action != models.AuditCreate {
+		var err error
+		before, err = t.snapshot(ctx, q, id)
+		if err != nil {
+			return err
+		}
+	}
+	if err := fn(before); err != nil {
+		return err
+	}
+	after, err := t.snapshot(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	// Pass untyped nils for missing rows so they diff as no fields at all
+	var beforeValue, afterValue any
+	if before != nil {
+		beforeValue = before
+	}
+	if after != nil {
+		afterValue = after
+	}
+	entry, err := audit.NewEntry(ctx, t.resource, id, action, beforeValue, afterValue)
+	if err != nil {
+		return err
+	}
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO audit_log (actor, resource, resource_id, action, changes, request_id)
+		VALUES (?, ?, ?, ?, ?, ?)
+		`, entry.Actor, entry.Resource, entry.ResourceID, entry.Action, changes, entry.RequestID)
+	return err
+}
+
+// softDelete marks the row id as deleted, returning ErrNotFound if it is
+// missing or already deleted. check, if not nil, may veto the delete of an
+// existing row.
+func (t auditedTable[T]) softDelete(ctx context.Context, q querier, id string, check func(before *T) error) error {
+	return t.change(ctx, q, id, models.AuditDelete, func(before *T) error {
+		if before != nil && check != nil {
+			if err := check(before); err != nil {
+				return err
+			}
+		}
+		result, err := q.ExecContext(ctx, "UPDATE "+t.table+" SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", id)
+		return checkAffected(result, err)
+	})
+}
+
+// softDeleteAll soft deletes every id in one transaction, failing with an
+// *ItemError wrapping ErrNotFound if any of them doesn't exist
+func (t auditedTable[T]) softDeleteAll(ctx context.Context, db *sql.DB, ids []string) error {
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		for i, id := range ids {
+			if err := t.softDelete(ctx, tx, id, nil); err != nil {
+				return &repository.ItemError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// restore clears the deletion mark of the row id, returning ErrNotFound
+// unless it is soft deleted
+func (t auditedTable[T]) restore(ctx context.Context, db *sql.DB, id string) error {
+	return inTx(ctx, db, func(tx *sql.Tx) error {
+		return t.change(ctx, tx, id, models.AuditRestore, func(*T) error {
+			result, err := tx.ExecContext(ctx, "UPDATE "+t.table+" SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+			return checkAffected(result, err)
+		})
+	})
+}
+
+// rewrite sets column to to on every row, deleted or not, where it equals
+// from, logging each row as updated. It is how lookup renames reach the rows
+// that refer to the lookup, which the foreign key cascade would change
+// behind the audit log and the row versions.
+func (t auditedTable[T]) rewrite(ctx context.Context, q querier, column, from, to string) error {
+	ids, err := selectIDs(ctx, q, "SELECT id FROM "+t.table+" WHERE "+column+" = ? FOR UPDATE", from)
+	if err != nil {
+		return err
+	}
+
+	set := column + " = ?"
+	if t.versioned {
+		set += ", version = version + 1"
+	}
+	for _, id := range ids {
+		err := t.change(ctx, q, id, models.AuditUpdate, func(*T) error {
+			_, err := q.ExecContext(ctx, "UPDATE "+t.table+" SET "+set+" WHERE id = ?", to, id)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// selectIDs runs a query selecting only ids
+func selectIDs(ctx context.Context, q querier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// purge hard deletes the rows soft deleted more than olderThan ago
+func (t auditedTable[T]) purge(ctx context.Context, db *sql.DB, olderThan time.Duration) (int, error) {
+	var purged int
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		ids, err := selectIDs(ctx, tx,
+			"SELECT id FROM "+t.table+" WHERE deleted_at < DATE_SUB(NOW(), INTERVAL ? SECOND) FOR UPDATE",
+			int64(olderThan.Seconds()),
+		)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			err := t.change(ctx, tx, id, models.AuditPurge, func(*T) error {
+				_, err := tx.ExecContext(ctx, "DELETE FROM "+t.table+" WHERE id = ?", id)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+		}
+		purged = len(ids)
+		return nil
+	})
+	return purged, err
+}
+
+type AuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// auditColumns maps the selectable columns onto the fields of entry. The
+// changes column is read as text into changes.
+func auditColumns(entry *models.AuditEntry, changes *string) []column {
+	return []column{
+		{name: "id", expr: "id", dest: &entry.ID},
+		{name: "actor", expr: "actor", dest: &entry.Actor},
+		{name: "resource", expr: "resource", dest: &entry.Resource},
+		{name: "resource_id", expr: "resource_id", dest: &entry.ResourceID},
+		{name: "action", expr: "action", dest: &entry.Action},
+		{name: "changes", expr: "CAST(changes AS CHAR)", dest: changes},
+		{name: "request_id", expr: "request_id", dest: &entry.RequestID},
+		{name: "created_at", expr: "created_at", dest: &entry.CreatedAt},
+	}
+}
+
+// scanAudit scans the columns named in fields, or all of them, into entry
+func scanAudit(row scanner, entry *models.AuditEntry, fields []string) error {
+	var changes string
+	_, dests := selectColumns(auditColumns(entry, &changes), fields)
+	if err := row.Scan(dests...); err != nil {
+		return err
+	}
+	if changes == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(changes), &entry.Changes)
+}
+
+func (repo *AuditRepository) List(ctx context.Context, opts repository.ListOptions) ([]models.AuditEntry, int, error) {
+	// Entries are never soft deleted; audit_log has no deleted_at column
+	opts.IncludeDeleted = true
+	list, _ := selectColumns(auditColumns(&models.AuditEntry{}, new(string)), opts.Fields)
+	query, args, queryCount, argsCount := buildListQuery(
+		"SELECT "+list+" FROM audit_log WHERE 1=1",
+		"SELECT COUNT(id) FROM audit_log WHERE 1=1",
+		nil,
+		opts,
+	)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := scanAudit(rows, &entry, opts.Fields); err != nil {
+			return nil, 0, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if !finishList(entries, opts) {
+		return entries, -1, nil
+	}
+
+	var total int
+	if err := repo.db.QueryRowContext(ctx, queryCount, argsCount...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
@@ -0,0 +1,56 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api/internal/models"
+)
+
+type ClassRepository struct {
+	table lookupTable
+}
+
+func NewClassRepository(db *sql.DB) *ClassRepository {
+	return &ClassRepository{table: lookupTable{
+		db:    db,
+		table: "classes",
+		refs: []lookupReference{
+			{column: "class", rewrite: teachersTable.rewrite},
+			{column: "class", rewrite: studentsTable.rewrite},
+		},
+	}}
+}
+
+func (repo *ClassRepository) List(ctx context.Context) ([]models.Class, error) {
+	names, err := repo.table.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	classList := make([]models.Class, len(names))
+	for i, name := range names {
+		classList[i] = models.Class{Name: name}
+	}
+	return classList, nil
+}
+
+func (repo *ClassRepository) Get(ctx context.Context, name string) (models.Class, error) {
+	name, err := repo.table.get(ctx, name)
+	return models.Class{Name: name}, err
+}
+
+func (repo *ClassRepository) Create(ctx context.Context, classes []models.Class) error {
+	names := make([]string, len(classes))
+	for i, class := range classes {
+		names[i] = class.Name
+	}
+	return repo.table.create(ctx, names)
+}
+
+func (repo *ClassRepository) Rename(ctx context.Context, name, newName string) error {
+	return repo.table.rename(ctx, name, newName)
+}
+
+func (repo *ClassRepository) Delete(ctx context.Context, name string) error {
+	return repo.table.delete(ctx, name)
+}
@@ -0,0 +1,242 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"time"
+)
+
+type ExecRepository struct {
+	db *sql.DB
+}
+
+func NewExecRepository(db *sql.DB) *ExecRepository {
+	return &ExecRepository{db: db}
+}
+
+// execColumns maps the selectable columns onto the fields of exec. It never
+// includes password or password_reset_token so they cannot end up in a
+// response.
+func execColumns(exec *models.Exec) []column {
+	return []column{
+		{name: "id", expr: "id", dest: &exec.ID},
+		{name: "first_name", expr: "first_name", dest: &exec.FirstName},
+		{name: "last_name", expr: "last_name", dest: &exec.LastName},
+		{name: "email", expr: "email", dest: &exec.Email},
+		{name: "username", expr: "username", dest: &exec.Username},
+		{name: "last_password_change", expr: "COALESCE(last_password_change, '')", dest: &exec.LastPasswordChange},
+		{name: "user_creation_time", expr: "COALESCE(user_creation_time, '')", dest: &exec.UserCreationTime},
+		{name: "role", expr: "role", dest: &exec.Role},
+		{name: "user_inactive", expr: "COALESCE(user_inactive, FALSE)", dest: &exec.UserInactive},
+		{name: "deleted_at", expr: "COALESCE(deleted_at, '')", dest: &exec.DeletedAt},
+	}
+}
+
+// execNullable are the columns of executives that may be NULL, which
+// buildListQuery sorts and pages by with NULL read as an empty string
+var execNullable = map[string]bool{
+	"last_password_change": true,
+	"user_creation_time":   true,
+	"deleted_at":           true,
+}
+
+// scanExec scans the columns named in fields, or all of them, into exec
+func scanExec(row scanner, exec *models.Exec, fields []string) error {
+	_, dests := selectColumns(execColumns(exec), fields)
+	return row.Scan(dests...)
+}
+
+// execSelect returns the SELECT list for fields
+func execSelect(fields []string) string {
+	list, _ := selectColumns(execColumns(&models.Exec{}), fields)
+	return list
+}
+
+func (repo *ExecRepository) List(ctx context.Context, opts repository.ListOptions) ([]models.Exec, int, error) {
+	query, args, queryCount, argsCount := buildListQuery(
+		"SELECT "+execSelect(opts.Fields)+" FROM executives WHERE 1=1",
+		"SELECT COUNT(id) FROM executives WHERE 1=1",
+		execNullable,
+		opts,
+	)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	execList := make([]models.Exec, 0)
+	for rows.Next() {
+		var exec models.Exec
+		if err := scanExec(rows, &exec, opts.Fields); err != nil {
+			return nil, 0, err
+		}
+		execList = append(execList, exec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if !finishList(execList, opts) {
+		return execList, -1, nil
+	}
+
+	var total int
+	if err := repo.db.QueryRowContext(ctx, queryCount, argsCount...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return execList, total, nil
+}
+
+func (repo *ExecRepository) Get(ctx context.Context, id string, fields ...string) (models.Exec, error) {
+	return repo.getBy(ctx, "id", id, fields)
+}
+
+func (repo *ExecRepository) GetByEmail(ctx context.Context, email string) (models.Exec, error) {
+	return repo.getBy(ctx, "email", email, nil)
+}
+
+func (repo *ExecRepository) getBy(ctx context.Context, column, value string, fields []string) (models.Exec, error) {
+	var exec models.Exec
+	err := scanExec(repo.db.QueryRowContext(ctx, "SELECT "+execSelect(fields)+" FROM executives WHERE "+column+" = ? AND deleted_at IS NULL", value), &exec, fields)
+	if err == sql.ErrNoRows {
+		return exec, repository.ErrNotFound
+	}
+	return exec, err
+}
+
+func (repo *ExecRepository) GetByUsername(ctx context.Context, username string) (models.Exec, error) {
+	var exec models.Exec
+	err := repo.db.QueryRowContext(ctx,
+		"SELECT id, username, password, role, COALESCE(user_inactive, FALSE) FROM executives WHERE username = ? AND deleted_at IS NULL",
+		username,
+	).Scan(&exec.ID, &exec.Username, &exec.Password, &exec.Role, &exec.UserInactive)
+	if err == sql.ErrNoRows {
+		return exec, repository.ErrNotFound
+	}
+	return exec, err
+}
+
+func (repo *ExecRepository) Create(ctx context.Context, execs []models.Exec) ([]models.Exec, error) {
+	addedExecs := make([]models.Exec, 0, len(execs))
+	err := inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		for i, newExec := range execs {
+			newExec.ID = uuid.New().String()
+			err := execsTable.change(ctx, tx, newExec.ID, models.AuditCreate, func(*models.Exec) error {
+				_, err := tx.ExecContext(ctx, `
+					INSERT INTO executives (id, first_name, last_name, email, username, password, role, user_inactive)
+					VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+					`, newExec.ID, newExec.FirstName, newExec.LastName, newExec.Email, newExec.Username, newExec.Password, newExec.Role, newExec.UserInactive)
+				if isDuplicateKey(err) {
+					return repository.ErrConflict
+				}
+				return err
+			})
+			if err != nil {
+				return &repository.ItemError{Index: i, Err: err}
+			}
+			newExec.Password = ""
+			addedExecs = append(addedExecs, newExec)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addedExecs, nil
+}
+
+func (repo *ExecRepository) Update(ctx context.Context, exec models.Exec) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		return updateExec(ctx, tx, exec)
+	})
+}
+
+func (repo *ExecRepository) UpdateMany(ctx context.Context, execs []models.Exec) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		for i, exec := range execs {
+			if err := updateExec(ctx, tx, exec); err != nil {
+				return &repository.ItemError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// updateExec updates exec and writes its audit entry
+func updateExec(ctx context.Context, q querier, exec models.Exec) error {
+	return execsTable.change(ctx, q, exec.ID, models.AuditUpdate, func(*models.Exec) error {
+		result, err := q.ExecContext(ctx, `
+			UPDATE executives
+			SET first_name = ?, last_name = ?, email = ?, username = ?, role = ?, user_inactive = ?
+			WHERE id = ? AND deleted_at IS NULL
+			`,
+			exec.FirstName,
+			exec.LastName,
+			exec.Email,
+			exec.Username,
+			exec.Role,
+			exec.UserInactive,
+			exec.ID,
+		)
+		return checkUpdate(ctx, q, result, err, "executives", exec.ID)
+	})
+}
+
+func (repo *ExecRepository) Delete(ctx context.Context, id string) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		return execsTable.softDelete(ctx, tx, id, nil)
+	})
+}
+
+func (repo *ExecRepository) DeleteMany(ctx context.Context, ids []string) error {
+	return execsTable.softDeleteAll(ctx, repo.db, ids)
+}
+
+func (repo *ExecRepository) Restore(ctx context.Context, id string) error {
+	return execsTable.restore(ctx, repo.db, id)
+}
+
+func (repo *ExecRepository) Purge(ctx context.Context, olderThan time.Duration) (int, error) {
+	return execsTable.purge(ctx, repo.db, olderThan)
+}
+
+func (repo *ExecRepository) SetResetToken(ctx context.Context, id, tokenHash string, ttl time.Duration) error {
+	result, err := repo.db.ExecContext(ctx,
+		"UPDATE executives SET password_reset_token = ?, reset_token_expiry = DATE_ADD(NOW(), INTERVAL ? SECOND) WHERE id = ? AND deleted_at IS NULL",
+		tokenHash, int(ttl.Seconds()), id,
+	)
+	return checkUpdate(ctx, repo.db, result, err, "executives", id)
+}
+
+// ResetPassword is audited as an update of last_password_change; the
+// password itself never reaches the audit log. Issuing a reset token with
+// SetResetToken changes no visible field and isn't audited.
+func (repo *ExecRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		var id string
+		err := tx.QueryRowContext(ctx,
+			"SELECT id FROM executives WHERE password_reset_token = ? AND reset_token_expiry > NOW() AND deleted_at IS NULL FOR UPDATE",
+			tokenHash,
+		).Scan(&id)
+		if err == sql.ErrNoRows {
+			return repository.ErrNotFound
+		} else if err != nil {
+			return err
+		}
+
+		return execsTable.change(ctx, tx, id, models.AuditUpdate, func(*models.Exec) error {
+			_, err := tx.ExecContext(ctx, `
+				UPDATE executives
+				SET password = ?, last_password_change = CURRENT_TIMESTAMP,
+					password_reset_token = NULL, reset_token_expiry = NULL
+				WHERE id = ?
+				`, passwordHash, id)
+			return err
+		})
+	})
+}
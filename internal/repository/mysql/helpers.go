@@ -0,0 +1,359 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"go-rest-api/internal/repository"
+	"regexp"
+	"slices"
+	"strings"
+
+	driver "github.com/go-sql-driver/mysql"
+)
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// column pairs a selectable SQL expression with the field it is scanned into.
+// name is the column's json name, as used by sparse fieldsets.
+type column struct {
+	name string
+	expr string
+	dest interface{}
+}
+
+// selectColumns returns the SELECT list and scan destinations of the columns
+// named in fields, or of every column when fields is empty. Unknown names are
+// ignored.
+func selectColumns(columns []column, fields []string) (string, []interface{}) {
+	exprs := make([]string, 0, len(columns))
+	dests := make([]interface{}, 0, len(columns))
+	for _, c := range columns {
+		if len(fields) > 0 && !slices.Contains(fields, c.name) {
+			continue
+		}
+		exprs = append(exprs, c.expr)
+		dests = append(dests, c.dest)
+	}
+	return strings.Join(exprs, ", "), dests
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// inTx runs fn in a transaction, committing only if it returns nil
+func inTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// dryRun runs fn in a transaction that is always rolled back, so nothing fn
+// writes is kept, and returns the error of fn
+func dryRun(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		if err := fn(tx); err != nil {
+			return err
+		}
+		return errDryRun
+	})
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+	return err
+}
+
+// checkEach runs insert for each of count items in a dry run, carrying on
+// past items that conflict or refer to a missing row. Each of those is rolled
+// back to a savepoint, so like a failed Create it doesn't affect the items
+// after it. The result joins an *ItemError for every such item; any other
+// error stops the check and is returned as an *ItemError alone.
+func checkEach(ctx context.Context, db *sql.DB, count int, insert func(tx *sql.Tx, i int) error) error {
+	var errs []error
+	err := dryRun(ctx, db, func(tx *sql.Tx) error {
+		for i := 0; i < count; i++ {
+			if _, err := tx.ExecContext(ctx, "SAVEPOINT check_item"); err != nil {
+				return err
+			}
+			err := insert(tx, i)
+			if err == nil {
+				continue
+			}
+			var refErr *repository.ReferenceError
+			if !errors.Is(err, repository.ErrConflict) && !errors.As(err, &refErr) {
+				return &repository.ItemError{Index: i, Err: err}
+			}
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT check_item"); err != nil {
+				return err
+			}
+			errs = append(errs, &repository.ItemError{Index: i, Err: err})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// buildListQuery appends the filters, sorting and pagination of opts to the
+// given SELECT and COUNT queries. Both queries must end in a WHERE clause on
+// a table with a deleted_at column, unless opts.IncludeDeleted is set. With a
+// keyset the rows of a backward page come out in reverse order; see
+// finishList. nullable names the table's columns that may be NULL; see
+// sortExpr.
+func buildListQuery(query, queryCount string, nullable map[string]bool, opts repository.ListOptions) (string, []interface{}, string, []interface{}) {
+	var args []interface{}
+	var argsCount []interface{}
+
+	if !opts.IncludeDeleted {
+		query += " AND deleted_at IS NULL"
+		queryCount += " AND deleted_at IS NULL"
+	}
+
+	for _, filter := range opts.Filters {
+		condition, values := filterCondition(filter)
+		query += " AND " + condition
+		queryCount += " AND " + condition
+		args = append(args, values...)
+		argsCount = append(argsCount, values...)
+	}
+
+	sort := opts.Sort
+	if opts.Keyset != nil {
+		sort = opts.KeysetSort()
+		if len(opts.Keyset.Values) > 0 {
+			condition, values := keysetCondition(sort, opts.Keyset, nullable)
+			query += " AND " + condition
+			args = append(args, values...)
+		}
+	}
+
+	if len(sort) > 0 {
+		clauses := make([]string, 0, len(sort))
+		for _, s := range sort {
+			order := s.Order
+			if opts.Keyset != nil && opts.Keyset.Backward {
+				order = reverseOrder(order)
+			}
+			clauses = append(clauses, sortExpr(s.Field, nullable)+" "+order)
+		}
+		query += " ORDER BY " + strings.Join(clauses, ", ")
+	}
+
+	if opts.Keyset != nil {
+		query += " LIMIT ?"
+		args = append(args, opts.Limit)
+	} else {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, opts.Limit, opts.Offset)
+	}
+
+	return query, args, queryCount, argsCount
+}
+
+// softDeleteNullable is the nullable column set, for buildListQuery, of a
+// table whose only nullable column is deleted_at
+var softDeleteNullable = map[string]bool{"deleted_at": true}
+
+// sortExpr is the expression rows are sorted and paged by for field. The
+// nullable columns, such as the exec timestamps, are selected as an empty
+// string when NULL, and a plain comparison with NULL is never true, so the
+// rows would drop out of cursor pages. Sorting those by the same COALESCE
+// keeps the rows, first in ascending order, and matches the key values
+// cursors carry. Other columns are used bare so their indexes still serve
+// the ORDER BY and the keyset seek.
+func sortExpr(field string, nullable map[string]bool) string {
+	if !nullable[field] {
+		return field
+	}
+	return "COALESCE(" + field + ", '')"
+}
+
+// keysetCondition selects the rows after keyset in the given order, or before
+// it for a backward keyset, comparing the sortExpr of each field:
+//
+//	(a > ?) OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?)
+func keysetCondition(sort []repository.SortField, keyset *repository.Keyset, nullable map[string]bool) (string, []interface{}) {
+	var disjuncts []string
+	var values []interface{}
+	for i, s := range sort {
+		var conjuncts []string
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, sortExpr(sort[j].Field, nullable)+" = ?")
+			values = append(values, keyset.Values[j])
+		}
+
+		comparison := ">"
+		if (s.Order == "desc") != keyset.Backward {
+			comparison = "<"
+		}
+		conjuncts = append(conjuncts, sortExpr(s.Field, nullable)+" "+comparison+" ?")
+		values = append(values, keyset.Values[i])
+
+		disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+	}
+	return "(" + strings.Join(disjuncts, " OR ") + ")", values
+}
+
+func reverseOrder(order string) string {
+	if order == "desc" {
+		return "asc"
+	}
+	return "desc"
+}
+
+// finishList puts the rows of a backward keyset page back into sort order
+// and reports whether the total still has to be counted
+func finishList[T any](items []T, opts repository.ListOptions) bool {
+	if opts.Keyset == nil {
+		return true
+	}
+	if opts.Keyset.Backward {
+		slices.Reverse(items)
+	}
+	return false
+}
+
+// comparisons maps the single-value operators onto SQL
+var comparisons = map[repository.Operator]string{
+	repository.OpEq:   "=",
+	repository.OpNe:   "<>",
+	repository.OpLike: "LIKE",
+	repository.OpGt:   ">",
+	repository.OpGte:  ">=",
+	repository.OpLt:   "<",
+	repository.OpLte:  "<=",
+}
+
+// filterCondition returns the SQL condition for filter and its arguments.
+// The field name is trusted; every value is a placeholder.
+func filterCondition(filter repository.Filter) (string, []interface{}) {
+	values := make([]interface{}, len(filter.Values))
+	for i, value := range filter.Values {
+		values[i] = value
+	}
+
+	switch filter.Op {
+	case repository.OpIn, repository.OpNin:
+		if len(values) == 0 {
+			if filter.Op == repository.OpIn {
+				return "1=0", nil
+			}
+			return "1=1", nil
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+		if filter.Op == repository.OpIn {
+			return filter.Field + " IN (" + placeholders + ")", values
+		}
+		return filter.Field + " NOT IN (" + placeholders + ")", values
+	}
+
+	comparison, ok := comparisons[filter.Op]
+	if !ok {
+		panic("mysql: unknown filter operator " + string(filter.Op))
+	}
+	return filter.Field + " " + comparison + " ?", values[:1]
+}
+
+// count runs a COUNT over the rows of table that match the filters and
+// aren't soft deleted
+func count(ctx context.Context, db querier, table string, filters []repository.Filter) (int, error) {
+	_, _, queryCount, argsCount := buildListQuery("", "SELECT COUNT(id) FROM "+table+" WHERE 1=1", nil, repository.ListOptions{Filters: filters})
+
+	var total int
+	err := db.QueryRowContext(ctx, queryCount, argsCount...).Scan(&total)
+	return total, err
+}
+
+// isDuplicateKey reports whether err is a unique constraint violation
+func isDuplicateKey(err error) bool {
+	var mysqlErr *driver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+// isReferenced reports whether err is a delete blocked by a foreign key
+func isReferenced(err error) bool {
+	var mysqlErr *driver.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1451
+}
+
+var foreignKeyColumn = regexp.MustCompile("FOREIGN KEY \\(`(\\w+)`\\)")
+
+// referenceError maps a foreign key violation of an INSERT or UPDATE onto a
+// *repository.ReferenceError, taking the offending value from values by
+// column name. Other errors are returned unchanged.
+func referenceError(err error, values map[string]string) error {
+	var mysqlErr *driver.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1452 {
+		return err
+	}
+	match := foreignKeyColumn.FindStringSubmatch(mysqlErr.Message)
+	if match == nil {
+		return err
+	}
+	return &repository.ReferenceError{Field: match[1], Value: values[match[1]]}
+}
+
+// checkUpdate maps the outcome of an UPDATE by id onto repository errors.
+// MySQL reports zero affected rows when nothing changed, so existence is
+// checked separately in that case. Soft deleted rows count as missing.
+func checkUpdate(ctx context.Context, db querier, result sql.Result, err error, table, id string) error {
+	if isDuplicateKey(err) {
+		return repository.ErrConflict
+	} else if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM "+table+" WHERE id = ? AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// checkAffected returns ErrNotFound when a statement expected to change a row
+// changed none
+func checkAffected(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package mysql
+
+import (
+	"go-rest-api/internal/repository"
+	"reflect"
+	"testing"
+)
+
+func TestBuildListQueryKeyset(t *testing.T) {
+	const base = "SELECT id FROM execs WHERE 1=1"
+	byChange := []repository.SortField{{Field: "last_password_change", Order: "desc"}}
+
+	tests := []struct {
+		name      string
+		keyset    *repository.Keyset
+		wantQuery string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "first page",
+			keyset:    &repository.Keyset{},
+			wantQuery: base + " AND deleted_at IS NULL ORDER BY COALESCE(last_password_change, '') desc, id asc LIMIT ?",
+			wantArgs:  []interface{}{3},
+		},
+		{
+			name:   "forward",
+			keyset: &repository.Keyset{Values: []string{"2025-05-01 10:00:00", "e1"}},
+			wantQuery: base + " AND deleted_at IS NULL" +
+				" AND ((COALESCE(last_password_change, '') < ?) OR (COALESCE(last_password_change, '') = ? AND id > ?))" +
+				" ORDER BY COALESCE(last_password_change, '') desc, id asc LIMIT ?",
+			wantArgs: []interface{}{"2025-05-01 10:00:00", "2025-05-01 10:00:00", "e1", 3},
+		},
+		{
+			name:   "backward",
+			keyset: &repository.Keyset{Values: []string{"2025-05-01 10:00:00", "e1"}, Backward: true},
+			wantQuery: base + " AND deleted_at IS NULL" +
+				" AND ((COALESCE(last_password_change, '') > ?) OR (COALESCE(last_password_change, '') = ? AND id < ?))" +
+				" ORDER BY COALESCE(last_password_change, '') asc, id desc LIMIT ?",
+			wantArgs: []interface{}{"2025-05-01 10:00:00", "2025-05-01 10:00:00", "e1", 3},
+		},
+		{
+			// A NULL timestamp is selected as '', so rows after it must
+			// compare against '' rather than NULL
+			name:   "from a NULL value",
+			keyset: &repository.Keyset{Values: []string{"", "e2"}},
+			wantQuery: base + " AND deleted_at IS NULL" +
+				" AND ((COALESCE(last_password_change, '') < ?) OR (COALESCE(last_password_change, '') = ? AND id > ?))" +
+				" ORDER BY COALESCE(last_password_change, '') desc, id asc LIMIT ?",
+			wantArgs: []interface{}{"", "", "e2", 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := repository.ListOptions{Sort: byChange, Limit: 3, Keyset: tt.keyset}
+			query, args, _, _ := buildListQuery(base, "", execNullable, opts)
+			if query != tt.wantQuery {
+				t.Errorf("query = %s\nwant    %s", query, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildListQueryLeavesNotNullColumnsBare(t *testing.T) {
+	const base = "SELECT id FROM teachers WHERE 1=1"
+	byName := []repository.SortField{{Field: "last_name", Order: "asc"}}
+
+	tests := []struct {
+		name      string
+		opts      repository.ListOptions
+		wantQuery string
+	}{
+		{
+			name:      "page",
+			opts:      repository.ListOptions{Sort: byName, Limit: 3},
+			wantQuery: base + " AND deleted_at IS NULL ORDER BY last_name asc LIMIT ? OFFSET ?",
+		},
+		{
+			name: "keyset",
+			opts: repository.ListOptions{Sort: byName, Limit: 3, Keyset: &repository.Keyset{Values: []string{"Reed", "t1"}}},
+			wantQuery: base + " AND deleted_at IS NULL" +
+				" AND ((last_name > ?) OR (last_name = ? AND id > ?))" +
+				" ORDER BY last_name asc, id asc LIMIT ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _, _, _ := buildListQuery(base, "", softDeleteNullable, tt.opts)
+			if query != tt.wantQuery {
+				t.Errorf("query = %s\nwant    %s", query, tt.wantQuery)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api/internal/repository"
+	"strings"
+)
+
+// lookupTable is a table with a single name column that other tables refer
+// to through foreign keys, such as classes
+type lookupTable struct {
+	db    *sql.DB
+	table string
+	refs  []lookupReference
+}
+
+// lookupReference is a column of an audited table that refers to a lookup
+// table. rewrite is the auditedTable method that renames its values.
+type lookupReference struct {
+	column  string
+	rewrite func(ctx context.Context, q querier, column, from, to string) error
+}
+
+func (t lookupTable) list(ctx context.Context) ([]string, error) {
+	rows, err := t.db.QueryContext(ctx, "SELECT name FROM "+t.table+" ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+func (t lookupTable) get(ctx context.Context, name string) (string, error) {
+	err := t.db.QueryRowContext(ctx, "SELECT name FROM "+t.table+" WHERE name = ?", name).Scan(&name)
+	if err == sql.ErrNoRows {
+		return name, repository.ErrNotFound
+	}
+	return name, err
+}
+
+func (t lookupTable) create(ctx context.Context, names []string) error {
+	return inTx(ctx, t.db, func(tx *sql.Tx) error {
+		for i, name := range names {
+			_, err := tx.ExecContext(ctx, "INSERT INTO "+t.table+" (name) VALUES (?)", name)
+			if isDuplicateKey(err) {
+				return &repository.ItemError{Index: i, Err: repository.ErrConflict}
+			} else if err != nil {
+				return &repository.ItemError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// rename changes name to newName in one transaction. It adds newName, moves
+// the referring rows over through the audited path, which also bumps their
+// versions, and only then removes name, so the ON UPDATE CASCADE of the
+// foreign keys never changes a row behind the audit log.
+func (t lookupTable) rename(ctx context.Context, name, newName string) error {
+	return inTx(ctx, t.db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, "SELECT name FROM "+t.table+" WHERE name = ? FOR UPDATE", name).Scan(&name)
+		if err == sql.ErrNoRows {
+			return repository.ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		if name == newName {
+			return nil
+		}
+
+		// Names compare case-insensitively, so a change of case alone can't
+		// insert newName first; the referring rows still match either way
+		caseOnly := strings.EqualFold(name, newName)
+		if !caseOnly {
+			_, err = tx.ExecContext(ctx, "INSERT INTO "+t.table+" (name) VALUES (?)", newName)
+			if isDuplicateKey(err) {
+				return repository.ErrConflict
+			} else if err != nil {
+				return err
+			}
+		}
+		for _, ref := range t.refs {
+			if err := ref.rewrite(ctx, tx, ref.column, name, newName); err != nil {
+				return err
+			}
+		}
+		if caseOnly {
+			_, err = tx.ExecContext(ctx, "UPDATE "+t.table+" SET name = ? WHERE name = ?", newName, name)
+		} else {
+			_, err = tx.ExecContext(ctx, "DELETE FROM "+t.table+" WHERE name = ?", name)
+		}
+		return err
+	})
+}
+
+func (t lookupTable) delete(ctx context.Context, name string) error {
+	result, err := t.db.ExecContext(ctx, "DELETE FROM "+t.table+" WHERE name = ?", name)
+	if isReferenced(err) {
+		return repository.ErrInUse
+	}
+	return checkAffected(result, err)
+}
@@ -0,0 +1,70 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api/internal/models"
+	"strings"
+)
+
+// SearchRepository searches the FULLTEXT indexes on first_name, last_name
+// and email of teachers, students and executives, skipping soft deleted rows
+type SearchRepository struct {
+	db *sql.DB
+}
+
+func NewSearchRepository(db *sql.DB) *SearchRepository {
+	return &SearchRepository{db: db}
+}
+
+// searchTables maps each table onto the result type it produces
+var searchTables = []struct {
+	table      string
+	resultType string
+}{
+	{"teachers", models.SearchTeacher},
+	{"students", models.SearchStudent},
+	{"executives", models.SearchExec},
+}
+
+const searchMatch = "MATCH(first_name, last_name, email) AGAINST (? IN NATURAL LANGUAGE MODE)"
+
+func (repo *SearchRepository) Search(ctx context.Context, query string, limit, offset int) ([]models.SearchResult, int, error) {
+	selects := make([]string, len(searchTables))
+	counts := make([]string, len(searchTables))
+	var args, argsCount []interface{}
+	for i, t := range searchTables {
+		selects[i] = "SELECT '" + t.resultType + "' AS type, id, first_name, last_name, email, " + searchMatch + " AS score FROM " + t.table + " WHERE deleted_at IS NULL AND " + searchMatch
+		counts[i] = "(SELECT COUNT(id) FROM " + t.table + " WHERE deleted_at IS NULL AND " + searchMatch + ")"
+		args = append(args, query, query)
+		argsCount = append(argsCount, query)
+	}
+
+	rows, err := repo.db.QueryContext(ctx,
+		strings.Join(selects, " UNION ALL ")+" ORDER BY score DESC, type, id LIMIT ? OFFSET ?",
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0)
+	for rows.Next() {
+		var result models.SearchResult
+		if err := rows.Scan(&result.Type, &result.ID, &result.FirstName, &result.LastName, &result.Email, &result.Score); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	var total int
+	if err := repo.db.QueryRowContext(ctx, "SELECT "+strings.Join(counts, " + "), argsCount...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return results, total, nil
+}
@@ -0,0 +1,214 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"time"
+)
+
+type StudentRepository struct {
+	db *sql.DB
+}
+
+func NewStudentRepository(db *sql.DB) *StudentRepository {
+	return &StudentRepository{db: db}
+}
+
+// studentColumns maps the selectable columns onto the fields of student
+func studentColumns(student *models.Student) []column {
+	return []column{
+		{name: "id", expr: "id", dest: &student.ID},
+		{name: "first_name", expr: "first_name", dest: &student.FirstName},
+		{name: "last_name", expr: "last_name", dest: &student.LastName},
+		{name: "email", expr: "email", dest: &student.Email},
+		{name: "class", expr: "class", dest: &student.Class},
+		{name: "deleted_at", expr: "COALESCE(deleted_at, '')", dest: &student.DeletedAt},
+	}
+}
+
+// scanStudent scans the columns named in fields, or all of them, into student
+func scanStudent(row scanner, student *models.Student, fields []string) error {
+	_, dests := selectColumns(studentColumns(student), fields)
+	return row.Scan(dests...)
+}
+
+// studentSelect returns the SELECT list for fields
+func studentSelect(fields []string) string {
+	list, _ := selectColumns(studentColumns(&models.Student{}), fields)
+	return list
+}
+
+func (repo *StudentRepository) List(ctx context.Context, opts repository.ListOptions) ([]models.Student, int, error) {
+	query, args, queryCount, argsCount := buildListQuery(
+		"SELECT "+studentSelect(opts.Fields)+" FROM students WHERE 1=1",
+		"SELECT COUNT(id) FROM students WHERE 1=1",
+		softDeleteNullable,
+		opts,
+	)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	studentList := make([]models.Student, 0)
+	for rows.Next() {
+		var student models.Student
+		if err := scanStudent(rows, &student, opts.Fields); err != nil {
+			return nil, 0, err
+		}
+		studentList = append(studentList, student)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if !finishList(studentList, opts) {
+		return studentList, -1, nil
+	}
+
+	var total int
+	if err := repo.db.QueryRowContext(ctx, queryCount, argsCount...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return studentList, total, nil
+}
+
+func (repo *StudentRepository) Count(ctx context.Context, filters []repository.Filter) (int, error) {
+	return count(ctx, repo.db, "students", filters)
+}
+
+func (repo *StudentRepository) Get(ctx context.Context, id string, fields ...string) (models.Student, error) {
+	var student models.Student
+	err := scanStudent(repo.db.QueryRowContext(ctx, "SELECT "+studentSelect(fields)+" FROM students WHERE id = ? AND deleted_at IS NULL", id), &student, fields)
+	if err == sql.ErrNoRows {
+		return student, repository.ErrNotFound
+	}
+	return student, err
+}
+
+func (repo *StudentRepository) Create(ctx context.Context, students []models.Student) ([]models.Student, error) {
+	var addedStudents []models.Student
+	err := inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		var err error
+		addedStudents, err = insertStudents(ctx, tx, students)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addedStudents, nil
+}
+
+func (repo *StudentRepository) CheckCreate(ctx context.Context, students []models.Student) error {
+	return checkEach(ctx, repo.db, len(students), func(tx *sql.Tx, i int) error {
+		_, err := insertStudent(ctx, tx, students[i])
+		return err
+	})
+}
+
+func (repo *StudentRepository) CreateEach(ctx context.Context, students []models.Student) ([]models.Student, []error) {
+	created := make([]models.Student, len(students))
+	errs := make([]error, len(students))
+	for i, newStudent := range students {
+		created[i] = newStudent
+		errs[i] = inTx(ctx, repo.db, func(tx *sql.Tx) error {
+			var err error
+			created[i], err = insertStudent(ctx, tx, newStudent)
+			return err
+		})
+	}
+	return created, errs
+}
+
+// insertStudents inserts every student, failing with an *ItemError at the
+// first one that can't be
+func insertStudents(ctx context.Context, q querier, students []models.Student) ([]models.Student, error) {
+	addedStudents := make([]models.Student, 0, len(students))
+	for i, newStudent := range students {
+		newStudent, err := insertStudent(ctx, q, newStudent)
+		if err != nil {
+			return nil, &repository.ItemError{Index: i, Err: err}
+		}
+		addedStudents = append(addedStudents, newStudent)
+	}
+	return addedStudents, nil
+}
+
+// insertStudent assigns an ID to student and inserts it with its audit entry
+func insertStudent(ctx context.Context, q querier, student models.Student) (models.Student, error) {
+	student.ID = uuid.New().String()
+	err := studentsTable.change(ctx, q, student.ID, models.AuditCreate, func(*models.Student) error {
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO students (id, first_name, last_name, class, email)
+			VALUES (?, ?, ?, ?, ?)
+			`, student.ID, student.FirstName, student.LastName, student.Class, student.Email)
+		if isDuplicateKey(err) {
+			return repository.ErrConflict
+		}
+		return referenceError(err, studentReferences(student))
+	})
+	return student, err
+}
+
+func (repo *StudentRepository) Update(ctx context.Context, student models.Student) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		return updateStudent(ctx, tx, student)
+	})
+}
+
+func (repo *StudentRepository) UpdateMany(ctx context.Context, students []models.Student) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		for i, student := range students {
+			if err := updateStudent(ctx, tx, student); err != nil {
+				return &repository.ItemError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// updateStudent updates student and writes its audit entry
+func updateStudent(ctx context.Context, q querier, student models.Student) error {
+	return studentsTable.change(ctx, q, student.ID, models.AuditUpdate, func(*models.Student) error {
+		result, err := q.ExecContext(ctx, `
+			UPDATE students
+			SET first_name = ?, last_name = ?, email = ?, class = ?
+			WHERE id = ? AND deleted_at IS NULL
+			`,
+			student.FirstName,
+			student.LastName,
+			student.Email,
+			student.Class,
+			student.ID,
+		)
+		return checkUpdate(ctx, q, result, referenceError(err, studentReferences(student)), "students", student.ID)
+	})
+}
+
+// studentReferences maps the foreign key columns of student to their values
+func studentReferences(student models.Student) map[string]string {
+	return map[string]string{"class": student.Class}
+}
+
+func (repo *StudentRepository) Delete(ctx context.Context, id string) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		return studentsTable.softDelete(ctx, tx, id, nil)
+	})
+}
+
+func (repo *StudentRepository) DeleteMany(ctx context.Context, ids []string) error {
+	return studentsTable.softDeleteAll(ctx, repo.db, ids)
+}
+
+func (repo *StudentRepository) Restore(ctx context.Context, id string) error {
+	return studentsTable.restore(ctx, repo.db, id)
+}
+
+func (repo *StudentRepository) Purge(ctx context.Context, olderThan time.Duration) (int, error) {
+	return studentsTable.purge(ctx, repo.db, olderThan)
+}
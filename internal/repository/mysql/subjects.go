@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"go-rest-api/internal/models"
+)
+
+type SubjectRepository struct {
+	table lookupTable
+}
+
+func NewSubjectRepository(db *sql.DB) *SubjectRepository {
+	return &SubjectRepository{table: lookupTable{
+		db:    db,
+		table: "subjects",
+		refs: []lookupReference{
+			{column: "subject", rewrite: teachersTable.rewrite},
+		},
+	}}
+}
+
+func (repo *SubjectRepository) List(ctx context.Context) ([]models.Subject, error) {
+	names, err := repo.table.list(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	subjectList := make([]models.Subject, len(names))
+	for i, name := range names {
+		subjectList[i] = models.Subject{Name: name}
+	}
+	return subjectList, nil
+}
+
+func (repo *SubjectRepository) Get(ctx context.Context, name string) (models.Subject, error) {
+	name, err := repo.table.get(ctx, name)
+	return models.Subject{Name: name}, err
+}
+
+func (repo *SubjectRepository) Create(ctx context.Context, subjects []models.Subject) error {
+	names := make([]string, len(subjects))
+	for i, subject := range subjects {
+		names[i] = subject.Name
+	}
+	return repo.table.create(ctx, names)
+}
+
+func (repo *SubjectRepository) Rename(ctx context.Context, name, newName string) error {
+	return repo.table.rename(ctx, name, newName)
+}
+
+func (repo *SubjectRepository) Delete(ctx context.Context, name string) error {
+	return repo.table.delete(ctx, name)
+}
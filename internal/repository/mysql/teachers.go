@@ -0,0 +1,242 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"github.com/google/uuid"
+	"go-rest-api/internal/models"
+	"go-rest-api/internal/repository"
+	"time"
+)
+
+type TeacherRepository struct {
+	db *sql.DB
+}
+
+func NewTeacherRepository(db *sql.DB) *TeacherRepository {
+	return &TeacherRepository{db: db}
+}
+
+// teacherColumns maps the selectable columns onto the fields of teacher
+func teacherColumns(teacher *models.Teacher) []column {
+	return []column{
+		{name: "id", expr: "id", dest: &teacher.ID},
+		{name: "first_name", expr: "first_name", dest: &teacher.FirstName},
+		{name: "last_name", expr: "last_name", dest: &teacher.LastName},
+		{name: "email", expr: "email", dest: &teacher.Email},
+		{name: "class", expr: "class", dest: &teacher.Class},
+		{name: "subject", expr: "subject", dest: &teacher.Subject},
+		{name: "deleted_at", expr: "COALESCE(deleted_at, '')", dest: &teacher.DeletedAt},
+		{name: "version", expr: "version", dest: &teacher.Version},
+	}
+}
+
+// scanTeacher scans the columns named in fields, or all of them, into teacher
+func scanTeacher(row scanner, teacher *models.Teacher, fields []string) error {
+	_, dests := selectColumns(teacherColumns(teacher), fields)
+	return row.Scan(dests...)
+}
+
+// teacherSelect returns the SELECT list for fields
+func teacherSelect(fields []string) string {
+	list, _ := selectColumns(teacherColumns(&models.Teacher{}), fields)
+	return list
+}
+
+func (repo *TeacherRepository) List(ctx context.Context, opts repository.ListOptions) ([]models.Teacher, int, error) {
+	query, args, queryCount, argsCount := buildListQuery(
+		"SELECT "+teacherSelect(opts.Fields)+" FROM teachers WHERE 1=1",
+		"SELECT COUNT(id) FROM teachers WHERE 1=1",
+		softDeleteNullable,
+		opts,
+	)
+
+	rows, err := repo.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	teacherList := make([]models.Teacher, 0)
+	for rows.Next() {
+		var teacher models.Teacher
+		if err := scanTeacher(rows, &teacher, opts.Fields); err != nil {
+			return nil, 0, err
+		}
+		teacherList = append(teacherList, teacher)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	if !finishList(teacherList, opts) {
+		return teacherList, -1, nil
+	}
+
+	var total int
+	if err := repo.db.QueryRowContext(ctx, queryCount, argsCount...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	return teacherList, total, nil
+}
+
+func (repo *TeacherRepository) Count(ctx context.Context, filters []repository.Filter) (int, error) {
+	return count(ctx, repo.db, "teachers", filters)
+}
+
+func (repo *TeacherRepository) Get(ctx context.Context, id string, fields ...string) (models.Teacher, error) {
+	var teacher models.Teacher
+	err := scanTeacher(repo.db.QueryRowContext(ctx, "SELECT "+teacherSelect(fields)+" FROM teachers WHERE id = ? AND deleted_at IS NULL", id), &teacher, fields)
+	if err == sql.ErrNoRows {
+		return teacher, repository.ErrNotFound
+	}
+	return teacher, err
+}
+
+func (repo *TeacherRepository) Create(ctx context.Context, teachers []models.Teacher) ([]models.Teacher, error) {
+	var addedTeachers []models.Teacher
+	err := inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		var err error
+		addedTeachers, err = insertTeachers(ctx, tx, teachers)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addedTeachers, nil
+}
+
+func (repo *TeacherRepository) CheckCreate(ctx context.Context, teachers []models.Teacher) error {
+	return checkEach(ctx, repo.db, len(teachers), func(tx *sql.Tx, i int) error {
+		_, err := insertTeacher(ctx, tx, teachers[i])
+		return err
+	})
+}
+
+// insertTeachers inserts every teacher, failing with an *ItemError at the
+// first one that can't be
+func insertTeachers(ctx context.Context, q querier, teachers []models.Teacher) ([]models.Teacher, error) {
+	addedTeachers := make([]models.Teacher, 0, len(teachers))
+	for i, newTeacher := range teachers {
+		newTeacher, err := insertTeacher(ctx, q, newTeacher)
+		if err != nil {
+			return nil, &repository.ItemError{Index: i, Err: err}
+		}
+		addedTeachers = append(addedTeachers, newTeacher)
+	}
+	return addedTeachers, nil
+}
+
+func (repo *TeacherRepository) CreateEach(ctx context.Context, teachers []models.Teacher) ([]models.Teacher, []error) {
+	created := make([]models.Teacher, len(teachers))
+	errs := make([]error, len(teachers))
+	for i, newTeacher := range teachers {
+		created[i] = newTeacher
+		errs[i] = inTx(ctx, repo.db, func(tx *sql.Tx) error {
+			var err error
+			created[i], err = insertTeacher(ctx, tx, newTeacher)
+			return err
+		})
+	}
+	return created, errs
+}
+
+// insertTeacher assigns an ID to teacher and inserts it with its audit entry
+func insertTeacher(ctx context.Context, q querier, teacher models.Teacher) (models.Teacher, error) {
+	teacher.ID = uuid.New().String()
+	teacher.Version = 1
+	err := teachersTable.change(ctx, q, teacher.ID, models.AuditCreate, func(*models.Teacher) error {
+		_, err := q.ExecContext(ctx, `
+			INSERT INTO teachers (id, first_name, last_name, subject, class, email)
+			VALUES (?, ?, ?, ?, ?, ?)
+			`, teacher.ID, teacher.FirstName, teacher.LastName, teacher.Subject, teacher.Class, teacher.Email)
+		if isDuplicateKey(err) {
+			return repository.ErrConflict
+		}
+		return referenceError(err, teacherReferences(teacher))
+	})
+	return teacher, err
+}
+
+// teacherReferences maps the foreign key columns of teacher to their values
+func teacherReferences(teacher models.Teacher) map[string]string {
+	return map[string]string{"class": teacher.Class, "subject": teacher.Subject}
+}
+
+func (repo *TeacherRepository) Update(ctx context.Context, teacher models.Teacher) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		return updateTeacher(ctx, tx, teacher)
+	})
+}
+
+func (repo *TeacherRepository) UpdateMany(ctx context.Context, teachers []models.Teacher) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		for i, teacher := range teachers {
+			if err := updateTeacher(ctx, tx, teacher); err != nil {
+				return &repository.ItemError{Index: i, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// updateTeacher updates teacher and writes its audit entry
+func updateTeacher(ctx context.Context, q querier, teacher models.Teacher) error {
+	return teachersTable.change(ctx, q, teacher.ID, models.AuditUpdate, func(*models.Teacher) error {
+		return updateTeacherRow(ctx, q, teacher)
+	})
+}
+
+func updateTeacherRow(ctx context.Context, q querier, teacher models.Teacher) error {
+	result, err := q.ExecContext(ctx, `
+		UPDATE teachers
+		SET first_name = ?, last_name = ?, email = ?, class = ?, subject = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
+		`,
+		teacher.FirstName,
+		teacher.LastName,
+		teacher.Email,
+		teacher.Class,
+		teacher.Subject,
+		teacher.ID,
+		teacher.Version,
+		teacher.Version,
+	)
+	if err := checkUpdate(ctx, q, result, referenceError(err, teacherReferences(teacher)), "teachers", teacher.ID); err != nil {
+		return err
+	}
+
+	// A matching row always changes because of the version, so an existing
+	// row left alone was updated by someone else first
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return repository.ErrVersionMismatch
+	}
+	return nil
+}
+
+func (repo *TeacherRepository) Delete(ctx context.Context, id string, version int) error {
+	return inTx(ctx, repo.db, func(tx *sql.Tx) error {
+		return teachersTable.softDelete(ctx, tx, id, func(before *models.Teacher) error {
+			if before.DeletedAt == "" && version != 0 && before.Version != version {
+				return repository.ErrVersionMismatch
+			}
+			return nil
+		})
+	})
+}
+
+func (repo *TeacherRepository) DeleteMany(ctx context.Context, ids []string) error {
+	return teachersTable.softDeleteAll(ctx, repo.db, ids)
+}
+
+func (repo *TeacherRepository) Restore(ctx context.Context, id string) error {
+	return teachersTable.restore(ctx, repo.db, id)
+}
+
+func (repo *TeacherRepository) Purge(ctx context.Context, olderThan time.Duration) (int, error) {
+	return teachersTable.purge(ctx, repo.db, olderThan)
+}
@@ -0,0 +1,282 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-rest-api/internal/models"
+	"slices"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("record not found")
+	ErrConflict = errors.New("record already exists")
+	ErrInUse    = errors.New("record is still referenced")
+	// ErrVersionMismatch means the record changed since the version given
+	// for an optimistic update was read
+	ErrVersionMismatch = errors.New("record was modified")
+)
+
+// ReferenceError reports a value that doesn't exist in the table a column
+// refers to, such as an unknown class
+type ReferenceError struct {
+	Field string
+	Value string
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("%s %q does not exist", e.Field, e.Value)
+}
+
+// ItemError reports which element of a bulk operation failed
+type ItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// SortField is one "field:order" entry of a sort_by query
+type SortField struct {
+	Field string
+	Order string // "asc" or "desc"
+}
+
+// Operator is the comparison a Filter applies
+type Operator string
+
+const (
+	OpEq   Operator = "eq"
+	OpNe   Operator = "ne"
+	OpLike Operator = "like" // SQL LIKE pattern with % and _
+	OpIn   Operator = "in"
+	OpNin  Operator = "nin"
+	OpGt   Operator = "gt"
+	OpGte  Operator = "gte"
+	OpLt   Operator = "lt"
+	OpLte  Operator = "lte"
+)
+
+// Filter is one "field[op]=value" condition of a list query. Values holds
+// a single value except for OpIn and OpNin.
+type Filter struct {
+	Field  string
+	Op     Operator
+	Values []string
+}
+
+// Eq returns the filter field = value
+func Eq(field, value string) Filter {
+	return Filter{Field: field, Op: OpEq, Values: []string{value}}
+}
+
+// Keyset positions a list just after a row for cursor pagination. Values
+// are the row's sort field values followed by its id; without values the
+// list starts at the first row.
+type Keyset struct {
+	Values []string
+	// Backward selects the rows just before the position instead. They are
+	// still returned in sort order.
+	Backward bool
+}
+
+// ListOptions describes a filtered, sorted page of a collection. Field names
+// must already be validated against the resource's whitelist by the caller.
+// Filters are combined with AND.
+//
+// With a Keyset, rows are ordered by Sort and then id, Offset is ignored,
+// and List skips counting, returning a total of -1.
+//
+// Fields limits the columns read to those json names; other fields of the
+// results are left empty. Repositories may fill them anyway.
+//
+// Soft deleted records are left out unless IncludeDeleted is set.
+type ListOptions struct {
+	Filters        []Filter
+	Sort           []SortField
+	Limit          int
+	Offset         int
+	Keyset         *Keyset
+	Fields         []string
+	IncludeDeleted bool
+}
+
+// KeysetSort returns the ordering used with a Keyset: opts.Sort followed
+// by id
+func (opts ListOptions) KeysetSort() []SortField {
+	return append(slices.Clone(opts.Sort), SortField{Field: "id", Order: "asc"})
+}
+
+// ClassRepository manages the classes teachers and students refer to. Write
+// methods of TeacherRepository and StudentRepository return a
+// *ReferenceError for a class that doesn't exist.
+type ClassRepository interface {
+	List(ctx context.Context) ([]models.Class, error)
+	Get(ctx context.Context, name string) (models.Class, error)
+	// Create inserts the classes in a single transaction. If any already
+	// exists nothing is stored and the error is an *ItemError.
+	Create(ctx context.Context, classes []models.Class) error
+	// Rename changes the name of a class and of every reference to it in one
+	// transaction. Each teacher and student it changes is logged as updated
+	// and teachers get a new version.
+	Rename(ctx context.Context, name, newName string) error
+	// Delete returns ErrInUse while teachers or students belong to the class
+	Delete(ctx context.Context, name string) error
+}
+
+// SubjectRepository manages the subjects teachers refer to, the same way as
+// ClassRepository
+type SubjectRepository interface {
+	List(ctx context.Context) ([]models.Subject, error)
+	Get(ctx context.Context, name string) (models.Subject, error)
+	// Create inserts the subjects in a single transaction. If any already
+	// exists nothing is stored and the error is an *ItemError.
+	Create(ctx context.Context, subjects []models.Subject) error
+	// Rename changes the name of a subject and of every reference to it
+	Rename(ctx context.Context, name, newName string) error
+	// Delete returns ErrInUse while teachers teach the subject
+	Delete(ctx context.Context, name string) error
+}
+
+// TeacherRepository treats soft deleted teachers as missing everywhere but
+// in List with IncludeDeleted, Restore and Purge. They still hold their
+// email and count as references to their class and subject.
+type TeacherRepository interface {
+	// List returns a page of teachers and the total number matching the filters
+	List(ctx context.Context, opts ListOptions) ([]models.Teacher, int, error)
+	// Count returns the number of teachers matching the filters
+	Count(ctx context.Context, filters []Filter) (int, error)
+	// Get reads only the given fields, by json name, when any are passed
+	Get(ctx context.Context, id string, fields ...string) (models.Teacher, error)
+	// Create assigns IDs and inserts the teachers in a single transaction,
+	// returning them with IDs set. If any insert fails nothing is stored and
+	// the error is an *ItemError.
+	Create(ctx context.Context, teachers []models.Teacher) ([]models.Teacher, error)
+	// CheckCreate reports every teacher Create would fail on, without
+	// storing anything. Unlike Create it carries on past conflicts and
+	// unknown references; the error joins an *ItemError for each.
+	CheckCreate(ctx context.Context, teachers []models.Teacher) error
+	// CreateEach inserts every teacher independently. Both results are
+	// aligned with the input; errs[i] is nil when teachers[i] was stored.
+	CreateEach(ctx context.Context, teachers []models.Teacher) (created []models.Teacher, errs []error)
+	// Update replaces the teacher and increments its version. A non-zero
+	// Version must match the stored one or ErrVersionMismatch is returned.
+	Update(ctx context.Context, teacher models.Teacher) error
+	// UpdateMany applies every update in one transaction, checking versions
+	// like Update. If any teacher is missing, conflicts or has changed
+	// nothing changes and the error is an *ItemError.
+	UpdateMany(ctx context.Context, teachers []models.Teacher) error
+	// Delete soft deletes the teacher. A non-zero version must match the
+	// stored one or ErrVersionMismatch is returned.
+	Delete(ctx context.Context, id string, version int) error
+	// DeleteMany soft deletes every id in one transaction. If any is missing
+	// nothing is deleted and the error is an *ItemError.
+	DeleteMany(ctx context.Context, ids []string) error
+	// Restore undeletes a soft deleted teacher. It returns ErrNotFound if
+	// there is no deleted teacher with the id.
+	Restore(ctx context.Context, id string) error
+	// Purge permanently removes the teachers soft deleted more than
+	// olderThan ago, returning how many there were
+	Purge(ctx context.Context, olderThan time.Duration) (int, error)
+}
+
+// StudentRepository treats soft deleted students as TeacherRepository treats
+// teachers
+type StudentRepository interface {
+	// List returns a page of students and the total number matching the filters
+	List(ctx context.Context, opts ListOptions) ([]models.Student, int, error)
+	// Count returns the number of students matching the filters
+	Count(ctx context.Context, filters []Filter) (int, error)
+	// Get reads only the given fields, by json name, when any are passed
+	Get(ctx context.Context, id string, fields ...string) (models.Student, error)
+	// Create assigns IDs and inserts the students in a single transaction,
+	// returning them with IDs set. If any insert fails nothing is stored and
+	// the error is an *ItemError.
+	Create(ctx context.Context, students []models.Student) ([]models.Student, error)
+	// CheckCreate reports every student Create would fail on, without
+	// storing anything. Unlike Create it carries on past conflicts and
+	// unknown references; the error joins an *ItemError for each.
+	CheckCreate(ctx context.Context, students []models.Student) error
+	// CreateEach inserts every student independently. Both results are
+	// aligned with the input; errs[i] is nil when students[i] was stored.
+	CreateEach(ctx context.Context, students []models.Student) (created []models.Student, errs []error)
+	Update(ctx context.Context, student models.Student) error
+	// UpdateMany applies every update in one transaction. If any student is
+	// missing or conflicts nothing changes and the error is an *ItemError.
+	UpdateMany(ctx context.Context, students []models.Student) error
+	// Delete soft deletes the student
+	Delete(ctx context.Context, id string) error
+	// DeleteMany soft deletes every id in one transaction. If any is missing
+	// nothing is deleted and the error is an *ItemError.
+	DeleteMany(ctx context.Context, ids []string) error
+	// Restore undeletes a soft deleted student. It returns ErrNotFound if
+	// there is no deleted student with the id.
+	Restore(ctx context.Context, id string) error
+	// Purge permanently removes the students soft deleted more than
+	// olderThan ago, returning how many there were
+	Purge(ctx context.Context, olderThan time.Duration) (int, error)
+}
+
+// ExecRepository never returns password hashes or reset tokens except from
+// GetByUsername, which login needs.
+//
+// As for teachers and students, every method but List with IncludeDeleted,
+// Restore and Purge treats soft deleted execs as missing.
+type ExecRepository interface {
+	// List returns a page of execs and the total number matching the filters
+	List(ctx context.Context, opts ListOptions) ([]models.Exec, int, error)
+	// Get reads only the given fields, by json name, when any are passed
+	Get(ctx context.Context, id string, fields ...string) (models.Exec, error)
+	// GetByUsername returns the exec including its password hash
+	GetByUsername(ctx context.Context, username string) (models.Exec, error)
+	GetByEmail(ctx context.Context, email string) (models.Exec, error)
+	// Create inserts execs whose Password field already holds a bcrypt hash
+	// in a single transaction. If any insert fails nothing is stored and the
+	// error is an *ItemError.
+	Create(ctx context.Context, execs []models.Exec) ([]models.Exec, error)
+	// Update changes profile fields only; the password hash is left untouched
+	Update(ctx context.Context, exec models.Exec) error
+	// UpdateMany applies every update in one transaction. If any exec is
+	// missing or conflicts nothing changes and the error is an *ItemError.
+	UpdateMany(ctx context.Context, execs []models.Exec) error
+	// Delete soft deletes the exec, who can no longer log in
+	Delete(ctx context.Context, id string) error
+	// DeleteMany soft deletes every id in one transaction. If any is missing
+	// nothing is deleted and the error is an *ItemError.
+	DeleteMany(ctx context.Context, ids []string) error
+	// Restore undeletes a soft deleted exec. It returns ErrNotFound if there
+	// is no deleted exec with the id.
+	Restore(ctx context.Context, id string) error
+	// Purge permanently removes the execs soft deleted more than olderThan
+	// ago, returning how many there were
+	Purge(ctx context.Context, olderThan time.Duration) (int, error)
+	// SetResetToken stores a hashed reset token valid for ttl
+	SetResetToken(ctx context.Context, id, tokenHash string, ttl time.Duration) error
+	// ResetPassword replaces the password of the exec holding an unexpired
+	// tokenHash and clears the token. It returns ErrNotFound if there is none.
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) error
+}
+
+// SearchRepository finds people across teachers, students and execs by name
+// and email
+type SearchRepository interface {
+	// Search returns a page of the people matching query, best matches
+	// first, and the total number of matches
+	Search(ctx context.Context, query string, limit, offset int) ([]models.SearchResult, int, error)
+}
+
+// AuditRepository reads the audit log. Entries are written by the teacher,
+// student and exec repositories, in the same transaction as each change;
+// the actor and request ID come from the context passed to them.
+type AuditRepository interface {
+	// List returns a page of entries and the total number matching the
+	// filters
+	List(ctx context.Context, opts ListOptions) ([]models.AuditEntry, int, error)
+}
@@ -5,8 +5,29 @@ import (
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	"os"
+	"strconv"
+	"time"
 )
 
+// PoolConfig holds the connection pool settings applied by ConnectDb
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// DefaultPoolConfig is used for any setting missing from the environment
+var DefaultPoolConfig = PoolConfig{
+	MaxOpenConns:    25,
+	MaxIdleConns:    25,
+	ConnMaxLifetime: 5 * time.Minute,
+	ConnMaxIdleTime: 5 * time.Minute,
+}
+
+// ConnectDb opens the connection pool shared by the whole server. It should
+// be called once on startup and the returned *sql.DB passed to whatever needs
+// it.
 func ConnectDb() (*sql.DB, error) {
 	fmt.Println("Connecting to database...")
 
@@ -16,6 +37,11 @@ func ConnectDb() (*sql.DB, error) {
 	dbport := os.Getenv("DB_PORT")
 	host := os.Getenv("HOST")
 
+	poolConfig, err := PoolConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
 	connectionString := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, password, host, dbport, dbname)
 	db, err := sql.Open("mysql", connectionString)
 	if err != nil {
@@ -23,11 +49,65 @@ func ConnectDb() (*sql.DB, error) {
 		return nil, err
 	}
 
+	db.SetMaxOpenConns(poolConfig.MaxOpenConns)
+	db.SetMaxIdleConns(poolConfig.MaxIdleConns)
+	db.SetConnMaxLifetime(poolConfig.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(poolConfig.ConnMaxIdleTime)
+
 	err = db.Ping()
 	if err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
 	fmt.Println("Connected to database...")
 	return db, nil
 }
+
+// PoolConfigFromEnv reads DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS,
+// DB_CONN_MAX_LIFETIME and DB_CONN_MAX_IDLE_TIME, falling back to
+// DefaultPoolConfig for unset values. Durations use time.ParseDuration syntax.
+func PoolConfigFromEnv() (PoolConfig, error) {
+	config := DefaultPoolConfig
+
+	if err := intFromEnv("DB_MAX_OPEN_CONNS", &config.MaxOpenConns); err != nil {
+		return config, err
+	}
+	if err := intFromEnv("DB_MAX_IDLE_CONNS", &config.MaxIdleConns); err != nil {
+		return config, err
+	}
+	if err := durationFromEnv("DB_CONN_MAX_LIFETIME", &config.ConnMaxLifetime); err != nil {
+		return config, err
+	}
+	if err := durationFromEnv("DB_CONN_MAX_IDLE_TIME", &config.ConnMaxIdleTime); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+func intFromEnv(key string, dest *int) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", key, err)
+	}
+	*dest = n
+	return nil
+}
+
+func durationFromEnv(key string, dest *time.Duration) error {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %v", key, err)
+	}
+	*dest = d
+	return nil
+}
@@ -0,0 +1,143 @@
+package validation
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError describes a problem with one field of the request
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Validator may be implemented by models that need checks the tags can't
+// express. It runs after the tag rules.
+type Validator interface {
+	Validate() []FieldError
+}
+
+var (
+	setsMu sync.RWMutex
+	sets   = make(map[string]map[string]bool)
+)
+
+// RegisterSet makes values available to the oneof=name rule
+func RegisterSet(name string, values []string) {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+
+	setsMu.Lock()
+	defer setsMu.Unlock()
+	sets[name] = set
+}
+
+func inSet(name, value string) bool {
+	setsMu.RLock()
+	defer setsMu.RUnlock()
+
+	set, ok := sets[name]
+	if !ok {
+		panic(fmt.Sprintf("validation: unknown set %q", name))
+	}
+	return set[value]
+}
+
+// Struct checks the string fields of v against their `validate` tags and
+// returns every failure. Fields are reported by their json name. Supported
+// rules, comma separated:
+//
+//	required   must not be empty
+//	max=N      at most N characters
+//	email      a bare email address
+//	oneof=set  one of the values registered with RegisterSet(set, ...)
+//
+// Rules other than required are skipped for empty values.
+func Struct(v any) []FieldError {
+	val := reflect.Indirect(reflect.ValueOf(v))
+	typ := val.Type()
+
+	var errs []FieldError
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("validate")
+		if tag == "" || field.Type.Kind() != reflect.String {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			name = field.Name
+		}
+
+		if fieldErr, ok := checkField(name, val.Field(i).String(), tag); !ok {
+			errs = append(errs, fieldErr)
+		}
+	}
+
+	if validator, ok := v.(Validator); ok {
+		errs = append(errs, validator.Validate()...)
+	}
+	return errs
+}
+
+// Slice validates every element of items, prefixing field names with the
+// element index, e.g. "[2].email"
+func Slice[T any](items []T) []FieldError {
+	var errs []FieldError
+	for i := range items {
+		for _, fieldErr := range Struct(&items[i]) {
+			fieldErr.Field = fmt.Sprintf("[%d].%s", i, fieldErr.Field)
+			errs = append(errs, fieldErr)
+		}
+	}
+	return errs
+}
+
+// checkField returns the first rule that value breaks
+func checkField(name, value, tag string) (FieldError, bool) {
+	for _, rule := range strings.Split(tag, ",") {
+		rule, param, _ := strings.Cut(rule, "=")
+
+		if rule == "required" {
+			if strings.TrimSpace(value) == "" {
+				return FieldError{Field: name, Code: "required", Message: name + " is required"}, false
+			}
+			continue
+		}
+		if value == "" {
+			continue
+		}
+
+		switch rule {
+		case "max":
+			limit, err := strconv.Atoi(param)
+			if err != nil {
+				panic(fmt.Sprintf("validation: bad max rule on %s: %v", name, err))
+			}
+			if utf8.RuneCountInString(value) > limit {
+				return FieldError{Field: name, Code: "too_long", Message: fmt.Sprintf("%s must be at most %d characters", name, limit)}, false
+			}
+		case "email":
+			addr, err := mail.ParseAddress(value)
+			if err != nil || addr.Address != value {
+				return FieldError{Field: name, Code: "invalid_email", Message: name + " must be a valid email address"}, false
+			}
+		case "oneof":
+			if !inSet(param, value) {
+				return FieldError{Field: name, Code: "not_allowed", Message: fmt.Sprintf("%s %q is not an allowed %s", name, value, param)}, false
+			}
+		default:
+			panic(fmt.Sprintf("validation: unknown rule %q on %s", rule, name))
+		}
+	}
+	return FieldError{}, true
+}
@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type person struct {
+	Name     string `json:"name,omitempty" validate:"required,max=5"`
+	Email    string `json:"email" validate:"required,email"`
+	Role     string `json:"role" validate:"oneof=test_role"`
+	Nickname string `validate:"max=3"`
+	Age      int    `json:"age" validate:"required"`
+}
+
+// checked adds a rule the tags can't express
+type checked struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func (c checked) Validate() []FieldError {
+	if c.Name == "root" {
+		return []FieldError{{Field: "name", Code: "reserved", Message: "name is reserved"}}
+	}
+	return nil
+}
+
+func init() {
+	RegisterSet("test_role", []string{"Principal", "Counselor"})
+}
+
+// codes renders errs as field:code pairs
+func codes(errs []FieldError) []string {
+	var got []string
+	for _, fieldErr := range errs {
+		got = append(got, fieldErr.Field+":"+fieldErr.Code)
+	}
+	return got
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want []string
+	}{
+		{"valid", &person{Name: "Ava", Email: "ava@school.test", Role: "Principal"}, nil},
+		{"required", &person{Name: "  "}, []string{"name:required", "email:required"}},
+		{"email", &person{Name: "Ava", Email: "Ava <ava@school.test>"}, []string{"email:invalid_email"}},
+		{"not allowed", &person{Name: "Ava", Email: "ava@school.test", Role: "Janitor"}, []string{"role:not_allowed"}},
+		{"too long", &person{Name: "Olivia", Email: "o@school.test"}, []string{"name:too_long"}},
+		{"go name without json tag", &person{Name: "Ava", Email: "ava@school.test", Nickname: "Avie"}, []string{"Nickname:too_long"}},
+		{"validator", checked{Name: "root"}, []string{"name:reserved"}},
+		{"validator after tags", checked{}, []string{"name:required"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := codes(Struct(tt.v)); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSliceIndexesFields(t *testing.T) {
+	people := []person{
+		{Name: "Ava", Email: "ava@school.test"},
+		{Name: "Ben", Email: "not-an-email"},
+		{Email: "cara@school.test", Role: "Janitor"},
+	}
+	want := []string{"[1].email:invalid_email", "[2].name:required", "[2].role:not_allowed"}
+	if got := codes(Slice(people)); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestMessagesNameTheField(t *testing.T) {
+	for _, fieldErr := range Struct(&person{Email: "x"}) {
+		if !strings.HasPrefix(fieldErr.Message, fieldErr.Field+" ") {
+			t.Errorf("message %q doesn't start with the field %q", fieldErr.Message, fieldErr.Field)
+		}
+	}
+}